@@ -0,0 +1,339 @@
+package bjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func (bj *bjson) ArrayIndicesWhere(pred func(index int, value BJSON) bool, targets ...string) ([]int, error) {
+	arr, err := bj.getArrayElement(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []int
+	for idx, v := range arr {
+		if pred(idx, &bjson{value: v, useNumber: bj.useNumber}) {
+			ret = append(ret, idx)
+		}
+	}
+
+	return ret, nil
+}
+
+// ArrayDuplicates returns the indices of the elements of the JSON array at targets that are
+// structurally equal to an earlier element.
+func (bj *bjson) ArrayDuplicates(targets ...string) ([]int, error) {
+	arr, err := bj.getArrayElement(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []int
+	for idx := range arr {
+		if containsValue(arr[:idx], arr[idx]) {
+			ret = append(ret, idx)
+		}
+	}
+
+	return ret, nil
+}
+
+// IndexArrayBy returns a new JSON object mapping the value of key in every element of the
+// JSON array at targets to a copy of that element. Key values must be strings, numbers or
+// booleans and are stringified; a missing key or a non-object element fails. A duplicate key
+// value fails unless keepLast is true, in which case the last element with that value wins.
+// The document is left unchanged.
+func (bj *bjson) IndexArrayBy(key string, keepLast bool, targets ...string) (BJSON, error) {
+	arr, err := bj.getArrayElement(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]interface{}, len(arr))
+	for idx, v := range arr {
+		elemPath := parseTracerPath(appendPath(targets, strconv.Itoa(idx)))
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("element %v is not a json object. got: %T", elemPath, v)
+		}
+
+		keyVal, ok := obj[key]
+		if !ok {
+			return nil, fmt.Errorf("key %v is not found at %v", key, elemPath)
+		}
+
+		keyStr, ok := scalarString(keyVal)
+		if !ok {
+			return nil, fmt.Errorf("key %v at %v is not a scalar. got: %T", key, elemPath, keyVal)
+		}
+
+		if _, isExist := ret[keyStr]; isExist && !keepLast {
+			return nil, fmt.Errorf("duplicate value %v for key %v at %v", keyStr, key, elemPath)
+		}
+
+		ret[keyStr] = v
+	}
+
+	nVal, err := deepCopy(ret, bj.useNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bjson{value: nVal, useNumber: bj.useNumber}, nil
+}
+
+// AlignArrayObjects adds the keys missing from each element of the JSON array at targets so
+// that every element holds the union of keys across all elements. Added keys are set to a
+// copy of fill, where a nil fill yields null. Every element must be a JSON object; otherwise
+// it fails and the document is left unchanged.
+func (bj *bjson) AlignArrayObjects(fill interface{}, targets ...string) error {
+	arr, err := bj.getArrayElement(targets)
+	if err != nil {
+		return err
+	}
+
+	fillVal, err := deepCopy(fill, bj.useNumber)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]struct{})
+	for idx, v := range arr {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("element %v is not a json object. got: %T", parseTracerPath(appendPath(targets, strconv.Itoa(idx))), v)
+		}
+
+		for key := range obj {
+			keys[key] = struct{}{}
+		}
+	}
+
+	for _, v := range arr {
+		obj := v.(map[string]interface{})
+		for key := range keys {
+			if _, isExist := obj[key]; isExist {
+				continue
+			}
+
+			if obj[key], err = deepCopy(fillVal, bj.useNumber); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ConcatAll concatenates, in match order, the JSON arrays matched by targets, which may
+// contain "*" wildcards (see resolveElements), into a new JSON array. A match that is not a
+// JSON array fails. The document is left unchanged.
+func (bj *bjson) ConcatAll(targets ...string) (BJSON, error) {
+	nodes, err := bj.resolveElements(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []interface{}{}
+	for _, node := range nodes {
+		arr, ok := node.value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("element %v is not a json array. got: %T", parseTracerPath(node.path), node.value)
+		}
+
+		ret = append(ret, arr...)
+	}
+
+	nVal, err := deepCopy(ret, bj.useNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bjson{value: nVal, useNumber: bj.useNumber}, nil
+}
+
+// identityKeys are the key names DiscoverKey prefers, in order.
+var identityKeys = []string{"id", "_id", "uuid", "key"}
+
+// DiscoverKey returns a key usable with IndexArrayBy and MergeArrayBy for the JSON array of
+// JSON objects at targets: a key present in every element whose values are strings, numbers
+// or booleans that are distinct once stringified. Candidates are tried in order: "id", "_id",
+// "uuid" and "key", then other keys ending with "id" (case-insensitive), then any other key,
+// alphabetically within each group. An empty array, a non-object element or no candidate fails.
+func (bj *bjson) DiscoverKey(targets ...string) (string, error) {
+	arr, err := bj.getArrayElement(targets)
+	if err != nil {
+		return "", err
+	}
+
+	if len(arr) == 0 {
+		return "", fmt.Errorf("element %v is an empty json array", parseTracerPath(targets))
+	}
+
+	objs := make([]map[string]interface{}, 0, len(arr))
+	for idx, v := range arr {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("element %v is not a json object. got: %T", parseTracerPath(appendPath(targets, strconv.Itoa(idx))), v)
+		}
+
+		objs = append(objs, obj)
+	}
+
+	var preferred, idLike, others []string
+	for _, key := range sortedKeys(objs[0]) {
+		switch {
+		case containsString(identityKeys, key):
+			continue
+		case strings.HasSuffix(strings.ToLower(key), "id"):
+			idLike = append(idLike, key)
+		default:
+			others = append(others, key)
+		}
+	}
+
+	for _, key := range identityKeys {
+		if _, isExist := objs[0][key]; isExist {
+			preferred = append(preferred, key)
+		}
+	}
+
+	for _, candidates := range [][]string{preferred, idLike, others} {
+		for _, key := range candidates {
+			if isUniqueKey(objs, key) {
+				return key, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no key uniquely identifies the elements of %v", parseTracerPath(targets))
+}
+
+func isUniqueKey(objs []map[string]interface{}, key string) bool {
+	seen := make(map[string]struct{}, len(objs))
+	for _, obj := range objs {
+		keyStr, ok := scalarString(obj[key])
+		if !ok {
+			return false
+		}
+
+		if _, isExist := seen[keyStr]; isExist {
+			return false
+		}
+
+		seen[keyStr] = struct{}{}
+	}
+
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SortArraysCanonical sorts, bottom-up, every JSON array under targets by the compact JSON
+// form of its elements, with object keys sorted. The result does not depend on the original
+// order of any array, so two documents holding the same sets compare equal afterwards. Array
+// order is lost: use it for comparison and hashing, not on data whose order matters.
+func (bj *bjson) SortArraysCanonical(targets ...string) error {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return err
+	}
+
+	nVal, _, err := sortArraysCanonical(sel.value)
+	if err != nil {
+		return err
+	}
+
+	return bj.SetElement(nVal, targets...)
+}
+
+// sortArraysCanonical returns a sorted rebuild of value together with its canonical form.
+func sortArraysCanonical(value interface{}) (interface{}, string, error) {
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(obj))
+		for key, child := range obj {
+			nChild, _, err := sortArraysCanonical(child)
+			if err != nil {
+				return nil, "", err
+			}
+
+			ret[key] = nChild
+		}
+
+		data, err := json.Marshal(ret)
+		return ret, string(data), err
+
+	case []interface{}:
+		type canonicalElem struct {
+			value interface{}
+			form  string
+		}
+
+		elems := make([]canonicalElem, len(obj))
+		for idx, child := range obj {
+			nChild, form, err := sortArraysCanonical(child)
+			if err != nil {
+				return nil, "", err
+			}
+
+			elems[idx] = canonicalElem{value: nChild, form: form}
+		}
+
+		sort.SliceStable(elems, func(i, j int) bool {
+			return elems[i].form < elems[j].form
+		})
+
+		ret := make([]interface{}, len(elems))
+		for idx, elem := range elems {
+			ret[idx] = elem.value
+		}
+
+		data, err := json.Marshal(ret)
+		return ret, string(data), err
+	}
+
+	data, err := json.Marshal(value)
+	return value, string(data), err
+}
+
+func scalarString(value interface{}) (string, bool) {
+	switch obj := value.(type) {
+	case string:
+		return obj, true
+	case float64:
+		return strconv.FormatFloat(obj, 'f', -1, 64), true
+	case json.Number:
+		return obj.String(), true
+	case bool:
+		return strconv.FormatBool(obj), true
+	}
+
+	return "", false
+}
+
+func (bj *bjson) getArrayElement(targets []string) ([]interface{}, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return nil, err
+	}
+
+	arr, ok := sel.value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("element %v is not a json array. got: %T", parseTracerPath(targets), sel.value)
+	}
+
+	return arr, nil
+}
@@ -0,0 +1,539 @@
+package bjson
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_bjson_ArrayIndicesWhere(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		pred    func(index int, value BJSON) bool
+		targets []string
+	}
+	isActive := func(index int, value BJSON) bool {
+		active, err := value.GetElement("active")
+		return err == nil && active.String() == `true`
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:    "success - match elements",
+			fields:  fields{value: `{"users":[{"active":true},{"active":false},"x",{"active":true}]}`},
+			args:    args{pred: isActive, targets: []string{"users"}},
+			want:    []int{0, 3},
+			wantErr: false,
+		},
+		{
+			name:    "success - match by index",
+			fields:  fields{value: `[1,2,3,4]`},
+			args:    args{pred: func(index int, value BJSON) bool { return index%2 == 1 }, targets: nil},
+			want:    []int{1, 3},
+			wantErr: false,
+		},
+		{
+			name:    "success - no match",
+			fields:  fields{value: `[]`},
+			args:    args{pred: isActive, targets: nil},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "fail - target is not a json array",
+			fields:  fields{value: `{"users":{}}`},
+			args:    args{pred: isActive, targets: []string{"users"}},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{pred: isActive, targets: []string{"users"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.ArrayIndicesWhere(tt.args.pred, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_ArrayDuplicates(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:    "success - duplicated elements",
+			fields:  fields{value: `{"arr":[1,"1",{"a":[1]},1.0,{"a":[1]},"1",null,null]}`},
+			args:    args{targets: []string{"arr"}},
+			want:    []int{3, 4, 5, 7},
+			wantErr: false,
+		},
+		{
+			name:    "success - no duplicate",
+			fields:  fields{value: `[1,2,3]`},
+			args:    args{targets: nil},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "fail - target is not a json array",
+			fields:  fields{value: `{"arr":"x"}`},
+			args:    args{targets: []string{"arr"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.ArrayDuplicates(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_IndexArrayBy(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		key      string
+		keepLast bool
+		targets  []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - index by string key",
+			fields:  fields{value: `{"users":[{"id":"a","n":1},{"id":"b","n":2}]}`},
+			args:    args{key: "id", targets: []string{"users"}},
+			want:    `{"a":{"id":"a","n":1},"b":{"id":"b","n":2}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - index by number and boolean key",
+			fields:  fields{value: `[{"id":10},{"id":2.5},{"id":true}]`},
+			args:    args{key: "id", targets: nil},
+			want:    `{"10":{"id":10},"2.5":{"id":2.5},"true":{"id":true}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - duplicate key value with keep last",
+			fields:  fields{value: `[{"id":1,"n":"a"},{"id":2,"n":"b"},{"id":1,"n":"c"}]`},
+			args:    args{key: "id", keepLast: true, targets: nil},
+			want:    `{"1":{"id":1,"n":"c"},"2":{"id":2,"n":"b"}}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - duplicate key value",
+			fields:  fields{value: `[{"id":1,"n":"a"},{"id":2,"n":"b"},{"id":1,"n":"c"}]`},
+			args:    args{key: "id", keepLast: false, targets: nil},
+			want:    ``,
+			wantErr: true,
+		},
+		{
+			name:    "fail - missing key",
+			fields:  fields{value: `[{"id":1},{}]`},
+			args:    args{key: "id", targets: nil},
+			want:    ``,
+			wantErr: true,
+		},
+		{
+			name:    "fail - non scalar key value",
+			fields:  fields{value: `[{"id":[1]}]`},
+			args:    args{key: "id", targets: nil},
+			want:    ``,
+			wantErr: true,
+		},
+		{
+			name:    "fail - non object element",
+			fields:  fields{value: `[1]`},
+			args:    args{key: "id", targets: nil},
+			want:    ``,
+			wantErr: true,
+		},
+		{
+			name:    "fail - target is not a json array",
+			fields:  fields{value: `{}`},
+			args:    args{key: "id", targets: nil},
+			want:    ``,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			got, err := bj.IndexArrayBy(tt.args.key, tt.args.keepLast, tt.args.targets...)
+			assert.Equal(t, before, bj.String())
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+
+			// the index must be independent of the document
+			for key := range got.(*bjson).value.(map[string]interface{}) {
+				assert.NoError(t, got.SetElement("changed", key, "id"))
+			}
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func Test_bjson_AlignArrayObjects(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		fill    interface{}
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - fill missing keys with null",
+			fields:  fields{value: `{"rows":[{"a":1},{"b":2},{"a":3,"c":{"x":1}}]}`},
+			args:    args{fill: nil, targets: []string{"rows"}},
+			want:    `{"rows":[{"a":1,"b":null,"c":null},{"a":null,"b":2,"c":null},{"a":3,"b":null,"c":{"x":1}}]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - fill missing keys with value",
+			fields:  fields{value: `[{"a":1},{}]`},
+			args:    args{fill: map[string]interface{}{"default": true}, targets: nil},
+			want:    `[{"a":1},{"a":{"default":true}}]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - empty array",
+			fields:  fields{value: `[]`},
+			args:    args{fill: 0, targets: nil},
+			want:    `[]`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - non-object element",
+			fields:  fields{value: `[{"a":1},{"b":2},"x"]`},
+			args:    args{fill: nil, targets: nil},
+			want:    `[{"a":1},{"b":2},"x"]`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - target is not a json array",
+			fields:  fields{value: `{"rows":{}}`},
+			args:    args{fill: nil, targets: []string{"rows"}},
+			want:    `{"rows":{}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.AlignArrayObjects(tt.args.fill, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_ConcatAll(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - concat pages in match order",
+			fields:  fields{value: `{"pages":{"2":{"items":[3]},"1":{"items":[1,2]},"3":{"other":[]}}}`},
+			args:    args{targets: []string{"pages", "*", "items"}},
+			want:    `[1,2,3]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - concat nested arrays of an array",
+			fields:  fields{value: `[[{"a":1}],[],[null,"x"]]`},
+			args:    args{targets: []string{"*"}},
+			want:    `[{"a":1},null,"x"]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - single array without wildcard",
+			fields:  fields{value: `{"a":[1]}`},
+			args:    args{targets: []string{"a"}},
+			want:    `[1]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - no match",
+			fields:  fields{value: `{"pages":[]}`},
+			args:    args{targets: []string{"pages", "*", "items"}},
+			want:    `[]`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - match is not a json array",
+			fields:  fields{value: `{"pages":[{"items":[1]},{"items":{}}]}`},
+			args:    args{targets: []string{"pages", "*", "items"}},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"pages", "*"}},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			got, err := bj.ConcatAll(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got.String())
+			}
+
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func Test_bjson_DiscoverKey(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - prefer id",
+			fields:  fields{value: `{"users":[{"id":1,"email":"a","name":"x"},{"id":2,"email":"b","name":"x"}]}`},
+			args:    args{targets: []string{"users"}},
+			want:    "id",
+			wantErr: false,
+		},
+		{
+			name:    "success - skip duplicated preferred key",
+			fields:  fields{value: `[{"id":1,"key":"a"},{"id":1,"key":"b"}]`},
+			args:    args{targets: nil},
+			want:    "key",
+			wantErr: false,
+		},
+		{
+			name:    "success - id-like key before other keys",
+			fields:  fields{value: `[{"a":1,"userId":"u1"},{"a":2,"userId":"u2"}]`},
+			args:    args{targets: nil},
+			want:    "userId",
+			wantErr: false,
+		},
+		{
+			name:    "success - any unique key",
+			fields:  fields{value: `[{"b":"x","c":true,"d":{}},{"b":"y","c":false,"d":{}}]`},
+			args:    args{targets: nil},
+			want:    "b",
+			wantErr: false,
+		},
+		{
+			name:    "fail - key missing from an element",
+			fields:  fields{value: `[{"id":1},{"name":"x"}]`},
+			args:    args{targets: nil},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - non-object element",
+			fields:  fields{value: `[{"id":1},2]`},
+			args:    args{targets: nil},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - empty array",
+			fields:  fields{value: `[]`},
+			args:    args{targets: nil},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.DiscoverKey(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_SortArraysCanonical(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - sort nested arrays bottom-up",
+			fields:  fields{value: `{"a":[3,1,2],"b":[{"x":[2,1]},{"x":[1,2]},"s",null,true],"c":{"d":["b","a"]}}`},
+			args:    args{targets: nil},
+			want:    `{"a":[1,2,3],"b":["s",null,true,{"x":[1,2]},{"x":[1,2]}],"c":{"d":["a","b"]}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - only under targets",
+			fields:  fields{value: `{"a":[2,1],"b":[2,1]}`},
+			args:    args{targets: []string{"b"}},
+			want:    `{"a":[2,1],"b":[1,2]}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.SortArraysCanonical(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_SortArraysCanonical_setEquality(t *testing.T) {
+	a, err := NewBJSON(`{"tags":[{"k":["y","x"]},"b","a"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewBJSON(`{"tags":["a",{"k":["x","y"]},"b"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, a.SortArraysCanonical())
+	assert.NoError(t, b.SortArraysCanonical())
+	assert.Equal(t, a.String(), b.String())
+}
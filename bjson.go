@@ -2,11 +2,18 @@ package bjson
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
+	"unicode/utf8"
 )
 
+// arrayAppend as the last target of AddElement or SetElement appends to the parent array.
+// In a JSON object it is an ordinary key.
+const arrayAppend = "-"
+
 func (bj *bjson) AddElement(value interface{}, targets ...string) (err error) {
 	return bj.updateElement(uoAdd, value, newTracer(targets))
 }
@@ -15,12 +22,359 @@ func (bj *bjson) GetElement(targets ...string) (BJSON, error) {
 	return bj.getElement(newTracer(targets))
 }
 
+// Exists reports whether targets resolve to an element, null included. Unlike GetElement it
+// builds no error for missing paths, so it is cheap to use in conditions.
+func (bj *bjson) Exists(targets ...string) bool {
+	value := bj.value
+	for _, target := range targets {
+		child, ok := directChild(value, target)
+		if !ok {
+			return false
+		}
+
+		value = child
+	}
+
+	return true
+}
+
+// ValueRef returns the element at targets without copying it. The returned value shares
+// memory with the document: it must not be mutated, and it may change when the document does.
+// Prefer Unmarshal or Copy unless the copy is a measured cost.
+func (bj *bjson) ValueRef(targets ...string) (interface{}, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return nil, err
+	}
+
+	return sel.value, nil
+}
+
+func (bj *bjson) GetString(targets ...string) (string, error) {
+	value, err := bj.getTyped(targets, JSONTypeString)
+	if err != nil {
+		return "", err
+	}
+
+	return value.(string), nil
+}
+
+// GetInt returns the number at targets as an int64. Numbers with a fractional part or out of
+// the int64 range fail instead of being truncated.
+func (bj *bjson) GetInt(targets ...string) (int64, error) {
+	value, err := bj.getTyped(targets, JSONTypeNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	// integer literals are read exactly, anything else such as 1e3 like a float64
+	if num, ok := value.(json.Number); ok {
+		if ret, err := num.Int64(); err == nil {
+			return ret, nil
+		}
+	}
+
+	num, ok := toFloat64(value)
+	if !ok || num != math.Trunc(num) || num < math.MinInt64 || num >= math.MaxInt64 {
+		return 0, fmt.Errorf("element %v is not an int64. got: %v", parseTracerPath(targets), value)
+	}
+
+	return int64(num), nil
+}
+
+func (bj *bjson) GetFloat64(targets ...string) (float64, error) {
+	value, err := bj.getTyped(targets, JSONTypeNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	ret, ok := toFloat64(value)
+	if !ok {
+		return 0, fmt.Errorf("element %v is not a float64. got: %v", parseTracerPath(targets), value)
+	}
+
+	return ret, nil
+}
+
+func (bj *bjson) GetBool(targets ...string) (bool, error) {
+	value, err := bj.getTyped(targets, JSONTypeBool)
+	if err != nil {
+		return false, err
+	}
+
+	return value.(bool), nil
+}
+
+// GetArray returns a deep copy of the JSON array at targets, so it can be mutated freely.
+func (bj *bjson) GetArray(targets ...string) ([]interface{}, error) {
+	value, err := bj.getTyped(targets, JSONTypeArray)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := deepCopy(value, bj.useNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.([]interface{}), nil
+}
+
+// GetMap returns a deep copy of the JSON object at targets, so it can be mutated freely.
+func (bj *bjson) GetMap(targets ...string) (map[string]interface{}, error) {
+	value, err := bj.getTyped(targets, JSONTypeObject)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := deepCopy(value, bj.useNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.(map[string]interface{}), nil
+}
+
+// Keys returns the keys of the JSON object at targets in sorted order. A JSON array has no
+// keys; use Len to get its number of elements.
+func (bj *bjson) Keys(targets ...string) ([]string, error) {
+	value, err := bj.getTyped(targets, JSONTypeObject)
+	if err != nil {
+		var typeErr *TypeError
+		if errors.As(err, &typeErr) && typeErr.Got == JSONTypeArray {
+			return nil, fmt.Errorf("%w. use Len to get the number of elements of a json array", err)
+		}
+
+		return nil, err
+	}
+
+	return sortedKeys(value.(map[string]interface{})), nil
+}
+
+// TypeOf returns the JSON type of the element at targets.
+func (bj *bjson) TypeOf(targets ...string) (JSONType, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return "", err
+	}
+
+	return typeOf(sel.value), nil
+}
+
+// getTyped returns the element at targets, failing with a *TypeError when it is not of type want.
+func (bj *bjson) getTyped(targets []string, want JSONType) (interface{}, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return nil, err
+	}
+
+	if got := typeOf(sel.value); got != want {
+		return nil, &TypeError{Path: parseTracerPath(targets), Want: want, Got: got}
+	}
+
+	return sel.value, nil
+}
+
 func (bj *bjson) SetElement(value interface{}, targets ...string) (err error) {
 	return bj.updateElement(uoSet, value, newTracer(targets))
 }
 
+// SetElementForce is SetElement that creates missing intermediate object keys as empty JSON
+// objects, like mkdir -p. It still fails when an intermediate element is a scalar or null, or
+// when an intermediate array index does not exist.
+func (bj *bjson) SetElementForce(value interface{}, targets ...string) error {
+	return bj.updateElement(uoSetForce, value, newTracer(targets))
+}
+
 func (bj *bjson) RemoveElement(targets ...string) (err error) {
-	return bj.updateElement(uoRemove, nil, newTracer(targets))
+	root := bj.shiftedMarshalRoot(targets, -1)
+	if err = bj.updateElement(uoRemove, nil, newTracer(targets)); err != nil {
+		return err
+	}
+
+	bj.marshalRoot = root
+	return nil
+}
+
+func (bj *bjson) TakeElement(targets ...string) (BJSON, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := sel.Copy()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = bj.RemoveElement(targets...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// MoveElement removes the element at from and places it at to. to is resolved after from is
+// removed. An existing element at to is replaced and a missing key is added to its parent
+// object. In an array parent, an index inserts the element before that index, counting from
+// the end when negative, and the array length or "-" appends it. On failure the document is
+// left unchanged. Moving an element onto itself is a no-op.
+func (bj *bjson) MoveElement(from []string, to []string) error {
+	if len(from) == len(to) && isPathPrefix(from, to) {
+		return nil
+	}
+
+	return bj.atomic(func(scratch *bjson) error {
+		sel, err := scratch.getElement(newTracer(from))
+		if err != nil {
+			return err
+		}
+
+		// a marshal root at or below from moves along with the element
+		var rootSuffix []string
+		paths := absolutePaths(scratch.value, [][]string{from, scratch.marshalRoot})
+		isRootMoved := len(scratch.marshalRoot) > 0 && isPathPrefix(paths[0], paths[1])
+		if isRootMoved {
+			rootSuffix = paths[1][len(paths[0]):]
+		}
+
+		if err = scratch.RemoveElement(from...); err != nil {
+			return err
+		}
+
+		placed, err := scratch.placeElement(sel.value, to)
+		if err != nil {
+			return err
+		}
+
+		if isRootMoved {
+			scratch.marshalRoot = appendPath(absolutePaths(scratch.value, [][]string{placed})[0], rootSuffix...)
+		}
+
+		return nil
+	})
+}
+
+// placeElement places value at targets like MoveElement and returns the path it was placed at,
+// with the index it was inserted at when the parent is a JSON array.
+func (bj *bjson) placeElement(value interface{}, targets []string) ([]string, error) {
+	if len(targets) == 0 {
+		return targets, bj.SetElement(value)
+	}
+
+	parentTargets, target := targets[:len(targets)-1], targets[len(targets)-1]
+	parent, err := bj.getElement(newTracer(parentTargets))
+	if err != nil {
+		return nil, err
+	}
+
+	arr, ok := parent.value.([]interface{})
+	if !ok {
+		if bj.Exists(targets...) {
+			return targets, bj.SetElement(value, targets...)
+		}
+
+		return targets, bj.AddElement(value, targets...)
+	}
+
+	idx, err := len(arr), error(nil)
+	if target != arrayAppend {
+		idx, err = strconv.Atoi(target)
+	}
+
+	if err == nil && idx < 0 {
+		idx += len(arr)
+	}
+
+	if err != nil || idx < 0 || idx > len(arr) {
+		return nil, fmt.Errorf("cannot insert element at %v. index %v out of range for array of length %v", parseTracerPath(targets), target, len(arr))
+	}
+
+	placed := appendPath(parentTargets, strconv.Itoa(idx))
+	root := bj.shiftedMarshalRoot(placed, 1)
+
+	nArr := make([]interface{}, 0, len(arr)+1)
+	nArr = append(nArr, arr[:idx]...)
+	nArr = append(nArr, value)
+	nArr = append(nArr, arr[idx:]...)
+	if err = bj.SetElement(nArr, parentTargets...); err != nil {
+		return nil, err
+	}
+
+	bj.marshalRoot = root
+	return placed, nil
+}
+
+// CopyElement adds a deep copy of the element at from at to with AddElement semantics, so the
+// copy is independent of its source and an existing object key at to is an error.
+func (bj *bjson) CopyElement(from []string, to []string) error {
+	sel, err := bj.getElement(newTracer(from))
+	if err != nil {
+		return err
+	}
+
+	return bj.AddElement(sel, to...)
+}
+
+// RenameKey renames the object key at targets to newKey and keeps its value. Go maps are
+// unordered, so the key order of the marshalled object is unaffected. Renaming a key to itself
+// is a no-op.
+func (bj *bjson) RenameKey(newKey string, targets ...string) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("cannot rename top level element")
+	}
+
+	parentTargets, oldKey := targets[:len(targets)-1], targets[len(targets)-1]
+	parent, err := bj.getTyped(parentTargets, JSONTypeObject)
+	if err != nil {
+		return err
+	}
+
+	obj := parent.(map[string]interface{})
+	value, isExist := obj[oldKey]
+	if !isExist {
+		return fmt.Errorf("element %v is not found", parseTracerPath(targets))
+	}
+
+	if newKey == oldKey {
+		return nil
+	}
+
+	if _, isExist = obj[newKey]; isExist {
+		return fmt.Errorf("key %v is already exist", parseTracerPath(appendPath(parentTargets, newKey)))
+	}
+
+	// a marshal root at or below the key follows it to newKey
+	if len(bj.marshalRoot) >= len(targets) {
+		paths := absolutePaths(bj.value, [][]string{targets, bj.marshalRoot})
+		if isPathPrefix(paths[0], paths[1]) {
+			paths[1][len(targets)-1] = newKey
+			bj.marshalRoot = paths[1]
+		}
+	}
+
+	obj[newKey] = value
+	delete(obj, oldKey)
+	return nil
+}
+
+func (bj *bjson) Focus(targets ...string) error {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return err
+	}
+
+	if len(bj.marshalRoot) > 0 {
+		paths := absolutePaths(bj.value, [][]string{targets, bj.marshalRoot})
+		if isPathPrefix(paths[0], paths[1]) {
+			bj.marshalRoot = appendPath(nil, paths[1][len(paths[0]):]...)
+		} else {
+			bj.marshalRoot = nil
+		}
+	}
+
+	bj.value = sel.value
+	return nil
 }
 
 func (bj *bjson) EscapeElement(targets ...string) error {
@@ -47,6 +401,46 @@ func (bj *bjson) EscapeElement(targets ...string) error {
 	return nil
 }
 
+func (bj *bjson) EscapedString(targets ...string) (string, error) {
+	element, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return "", err
+	}
+
+	elementStr := element.String()
+	if elementStr == `""` {
+		return elementStr, nil
+	}
+
+	ret, err := json.Marshal(elementStr)
+	if err != nil {
+		return "", fmt.Errorf("fail to escape element. value: %v. %v", elementStr, err)
+	}
+
+	return string(ret), nil
+}
+
+// EscapeSizeDelta returns the size in bytes of EscapedString minus the size of the compact
+// element at targets. A negative delta means escaping saves bytes. The document is left unchanged.
+func (bj *bjson) EscapeSizeDelta(targets ...string) (int, error) {
+	escaped, err := bj.EscapedString(targets...)
+	if err != nil {
+		return 0, err
+	}
+
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := json.Marshal(sel.value)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(escaped) - len(current), nil
+}
+
 func (bj *bjson) UnescapeElement(targets ...string) error {
 	element, err := bj.getElement(newTracer(targets))
 	if err != nil {
@@ -63,8 +457,8 @@ func (bj *bjson) UnescapeElement(targets ...string) error {
 		return fmt.Errorf("fail to unescape element. value: %v. %v", elementStr, err)
 	}
 
-	var nVal interface{}
-	if err = json.Unmarshal([]byte(elementUesc), &nVal); err != nil {
+	nVal, err := unmarshalValue([]byte(elementUesc), bj.useNumber)
+	if err != nil {
 		return fmt.Errorf("fail to marshal element from unescaped value: %v. %v", elementUesc, err)
 	}
 
@@ -75,24 +469,74 @@ func (bj *bjson) UnescapeElement(targets ...string) error {
 	return nil
 }
 
+// CanonicalizeEscaping repeatedly unescapes a string root holding JSON until the root is a
+// JSON object or JSON array. A root that never becomes one, such as a plain string or a string
+// holding a number, is left untouched.
+func (bj *bjson) CanonicalizeEscaping() error {
+	nVal := bj.value
+	for {
+		str, ok := nVal.(string)
+		if !ok {
+			break
+		}
+
+		var err error
+		if nVal, err = deepCopy([]byte(str), bj.useNumber); err != nil {
+			return nil
+		}
+	}
+
+	switch nVal.(type) {
+	case map[string]interface{}, []interface{}:
+		bj.value = nVal
+	}
+
+	return nil
+}
+
+// Len returns the number of keys of a JSON object, the number of elements of a JSON array and
+// the number of characters (runes) of a JSON string. Numbers, booleans and null have length 0.
 func (bj *bjson) Len() int {
 	switch valObj := bj.value.(type) {
 	case map[string]interface{}:
 		return len(valObj)
 	case []interface{}:
 		return len(valObj)
+	case string:
+		return utf8.RuneCountInString(valObj)
 	}
 
 	return 0
 }
 
+// Copy returns a deep copy of the document that keeps its marshal root. It reads the whole
+// document, so it must not run while another goroutine mutates it; share documents through
+// SafeBJSON instead.
 func (bj *bjson) Copy() (BJSON, error) {
-	nVal, err := deepCopy(bj.value)
+	nVal, err := deepCopy(bj.value, bj.useNumber)
 	if err != nil {
 		return nil, err
 	}
 
-	return &bjson{value: nVal}, nil
+	return &bjson{value: nVal, marshalRoot: appendPath(nil, bj.marshalRoot...), useNumber: bj.useNumber}, nil
+}
+
+// Adopt replaces the document with a deep copy of other. Only the document value is replaced;
+// anything else configured on the receiver is kept, so a configured instance can be reused
+// across payloads.
+func (bj *bjson) Adopt(other BJSON) error {
+	otherVal, err := valueOf(other)
+	if err != nil {
+		return err
+	}
+
+	nVal, err := deepCopy(otherVal, bj.useNumber)
+	if err != nil {
+		return err
+	}
+
+	bj.value = nVal
+	return nil
 }
 
 func (bj *bjson) String() string {
@@ -100,17 +544,92 @@ func (bj *bjson) String() string {
 	return string(ret)
 }
 
+// SetMarshalRoot makes Marshal, its variants and String resolve their targets below targets,
+// so they emit only that sub-tree until ClearMarshalRoot is called. targets must resolve when
+// the root is set. The root follows the element through Focus, MoveElement, RenameKey and
+// JSON array insertions and removals that shift its index, including those made inside
+// transactions. Removing the root or one of its ancestors clears it, and a root that no
+// longer resolves after any other change is ignored, so the whole document is emitted.
+// Other methods are not affected.
+func (bj *bjson) SetMarshalRoot(targets ...string) error {
+	if _, err := bj.getElement(newTracer(targets)); err != nil {
+		return err
+	}
+
+	bj.marshalRoot = appendPath(nil, targets...)
+	return nil
+}
+
+func (bj *bjson) ClearMarshalRoot() {
+	bj.marshalRoot = nil
+}
+
+// shiftedMarshalRoot returns the marshal root once the element at targets is removed from
+// (delta -1) or inserted into (delta 1) its parent. It is nil when the root is removed along
+// with the element, and its index is shifted when the parent is a JSON array holding the root
+// at or after targets.
+func (bj *bjson) shiftedMarshalRoot(targets []string, delta int) []string {
+	if len(bj.marshalRoot) == 0 || len(targets) == 0 {
+		return bj.marshalRoot
+	}
+
+	paths := absolutePaths(bj.value, [][]string{targets, bj.marshalRoot})
+	target, root := paths[0], paths[1]
+	if delta < 0 && isPathPrefix(target, root) {
+		return nil
+	}
+
+	n := len(target) - 1
+	if len(root) <= n || !isPathPrefix(target[:n], root) {
+		return bj.marshalRoot
+	}
+
+	parent, err := bj.getElement(newTracer(target[:n]))
+	if err != nil {
+		return bj.marshalRoot
+	}
+
+	idx, err := strconv.Atoi(target[n])
+	rootIdx, rootErr := strconv.Atoi(root[n])
+	if _, isArr := parent.value.([]interface{}); !isArr || err != nil || rootErr != nil || idx > rootIdx {
+		return bj.marshalRoot
+	}
+
+	root[n] = strconv.Itoa(rootIdx + delta)
+	return root
+}
+
+// getMarshalElement returns the element at targets below the marshal root.
+func (bj *bjson) getMarshalElement(targets []string) (*bjson, error) {
+	if len(bj.marshalRoot) == 0 {
+		return bj.getElement(newTracer(targets))
+	}
+
+	// a root left dangling by a change that does not track it, such as Merge, is ignored
+	if _, err := bj.getElement(newTracer(bj.marshalRoot)); err != nil {
+		return bj.getElement(newTracer(targets))
+	}
+
+	return bj.getElement(newTracer(appendPath(bj.marshalRoot, targets...)))
+}
+
 func (bj *bjson) Marshal(isPretty bool, targets ...string) ([]byte, error) {
-	sel, err := bj.getElement(newTracer(targets))
+	sel, err := bj.getMarshalElement(targets)
 	if err != nil {
 		return nil, err
 	}
 
-	if isPretty {
-		return json.MarshalIndent(sel.value, "", "\t")
+	return marshalValue(sel.value, isPretty)
+}
+
+// MarshalIndent is Marshal with the prefix and indent of json.MarshalIndent instead of a tab.
+func (bj *bjson) MarshalIndent(prefix, indent string, targets ...string) ([]byte, error) {
+	sel, err := bj.getMarshalElement(targets)
+	if err != nil {
+		return nil, err
 	}
 
-	return json.Marshal(sel.value)
+	return json.MarshalIndent(sel.value, prefix, indent)
 }
 
 func (bj *bjson) MarshalWrite(path string, isPretty bool, targets ...string) error {
@@ -123,7 +642,12 @@ func (bj *bjson) MarshalWrite(path string, isPretty bool, targets ...string) err
 }
 
 func (bj *bjson) Unmarshal(v any, targets ...string) error {
-	d, err := bj.Marshal(false, targets...)
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return err
+	}
+
+	d, err := json.Marshal(sel.value)
 	if err != nil {
 		return err
 	}
@@ -143,13 +667,9 @@ func (bj *bjson) getElement(tc *tracer) (*bjson, error) {
 			}
 
 		case []interface{}:
-			idx, err := strconv.Atoi(tc.currTarget())
+			idx, err := parseArrayIndex(obj, tc)
 			if err != nil {
-				return nil, fmt.Errorf("element %v is not valid index (int) for JSON array. %v", tc.passedPath(), err)
-			}
-
-			if idx < 0 || idx > len(obj)-1 {
-				return nil, fmt.Errorf("invalid index for json array at %v", tc.passedPath())
+				return nil, err
 			}
 
 			sel = obj[idx]
@@ -159,13 +679,118 @@ func (bj *bjson) getElement(tc *tracer) (*bjson, error) {
 		}
 	}
 
-	return &bjson{value: sel}, nil
+	return &bjson{value: sel, useNumber: bj.useNumber}, nil
+}
+
+// GetLenient resolves targets like GetElement, but when a target does not resolve
+// against the current element it retries after unwrapping that element:
+//   - a JSON array with exactly one element is replaced by that element
+//   - a JSON object with exactly one key is replaced by the value of that key
+//
+// Unwrapping repeats until the target resolves or neither rule applies, in which case
+// the not found error is returned.
+func (bj *bjson) GetLenient(targets ...string) (BJSON, error) {
+	tc := newTracer(targets)
+	sel := bj.value
+	for tc.next() {
+		for {
+			child, ok := directChild(sel, tc.currTarget())
+			if ok {
+				sel = child
+				break
+			}
+
+			switch obj := sel.(type) {
+			case []interface{}:
+				if len(obj) == 1 {
+					sel = obj[0]
+					continue
+				}
+
+			case map[string]interface{}:
+				if len(obj) == 1 {
+					for _, v := range obj {
+						sel = v
+					}
+					continue
+				}
+			}
+
+			return nil, fmt.Errorf("element %v is not found. target: %v", tc.passedPath(), tc.originPath())
+		}
+	}
+
+	return &bjson{value: sel, useNumber: bj.useNumber}, nil
+}
+
+// GetClosest resolves as many targets as possible and returns the deepest resolved element
+// with the number of targets it matched. When targets do not fully resolve, the error
+// describes the first target that failed alongside the partial result.
+func (bj *bjson) GetClosest(targets ...string) (BJSON, int, error) {
+	sel := bj.value
+	for depth, target := range targets {
+		child, ok := directChild(sel, target)
+		if !ok {
+			_, err := bj.getElement(newTracer(targets[:depth+1]))
+			return &bjson{value: sel, useNumber: bj.useNumber}, depth, err
+		}
+
+		sel = child
+	}
+
+	return &bjson{value: sel, useNumber: bj.useNumber}, len(targets), nil
+}
+
+func directChild(parent interface{}, target string) (interface{}, bool) {
+	switch obj := parent.(type) {
+	case map[string]interface{}:
+		child, ok := obj[target]
+		return child, ok
+
+	case []interface{}:
+		idx, err := strconv.Atoi(target)
+		if err != nil {
+			return nil, false
+		}
+
+		if idx < 0 {
+			idx += len(obj)
+		}
+
+		if idx < 0 || idx > len(obj)-1 {
+			return nil, false
+		}
+
+		return obj[idx], true
+	}
+
+	return nil, false
+}
+
+// parseArrayIndex converts the current target of tc into an index of arr. A negative index
+// counts from the end of arr, so "-1" is its last element.
+func parseArrayIndex(arr []interface{}, tc *tracer) (int, error) {
+	idx, err := strconv.Atoi(tc.currTarget())
+	if err != nil {
+		return 0, fmt.Errorf("element %v is not valid index (int) for JSON array. %v", tc.passedPath(), err)
+	}
+
+	pos := idx
+	if pos < 0 {
+		pos += len(arr)
+	}
+
+	if pos < 0 || pos > len(arr)-1 {
+		return 0, fmt.Errorf("index %v out of range for array of length %v at %v", idx, len(arr), tc.parentPath())
+	}
+
+	return pos, nil
 }
 
 func (bj *bjson) updateElement(opt updateOption, value interface{}, tc *tracer) error {
 	if value != nil {
 		var err error
-		value, err = deepCopy(value)
+		value, err = deepCopy(value, bj.useNumber)
 		if err != nil {
 			return err
 		}
@@ -192,7 +817,7 @@ func (bj *bjson) updateTopLevelElement(opt updateOption, value interface{}) erro
 			return nil
 		}
 
-	case uoSet:
+	case uoSet, uoSetForce:
 		bj.value = value
 		return nil
 	}
@@ -210,6 +835,10 @@ func (bj *bjson) recursiveUpdateElement(opt updateOption, parent interface{}, va
 				return nil, fmt.Errorf("element %v is not found. target: %v", tc.passedPath(), tc.originPath())
 			}
 
+			if !isExist && opt == uoSetForce && !tc.isTail() {
+				child = map[string]interface{}{}
+			}
+
 			if tc.isTail() {
 				return bj.updateTailMapElement(opt, obj, value, child, isExist, tc)
 			}
@@ -222,13 +851,13 @@ func (bj *bjson) recursiveUpdateElement(opt updateOption, parent interface{}, va
 			obj[target] = updatedChild
 
 		case []interface{}:
-			idx, err := strconv.Atoi(target)
-			if err != nil {
-				return nil, fmt.Errorf("element %v is not valid index (int) for JSON array. %v", tc.passedPath(), err)
+			if tc.isTail() && target == arrayAppend && opt != uoRemove {
+				return append(obj, value), nil
 			}
 
-			if idx < 0 || idx > len(obj)-1 {
-				return nil, fmt.Errorf("invalid index for json array at %v", tc.passedPath())
+			idx, err := parseArrayIndex(obj, tc)
+			if err != nil {
+				return nil, err
 			}
 
 			if tc.isTail() {
@@ -266,7 +895,7 @@ func (bj *bjson) updateTailMapElement(opt updateOption, obj map[string]interface
 
 		fallthrough
 
-	case uoSet:
+	case uoSet, uoSetForce:
 		obj[tc.currTarget()] = value
 
 	case uoRemove:
@@ -287,44 +916,121 @@ func (bj *bjson) updateTailArrayElement(opt updateOption, parentObj []interface{
 
 		parentObj[idx] = append(arr, value)
 
-	case uoSet:
+	case uoSet, uoSetForce:
 		parentObj[idx] = value
 
 	case uoRemove:
-		parentObj = append(parentObj[:idx], parentObj[idx+1:]...)
+		// Build a fresh slice so views sharing the old backing array are left intact.
+		nParentObj := make([]interface{}, 0, len(parentObj)-1)
+		nParentObj = append(nParentObj, parentObj[:idx]...)
+		parentObj = append(nParentObj, parentObj[idx+1:]...)
 	}
 
 	return parentObj, nil
 }
 
-func deepCopy(data interface{}) (interface{}, error) {
-	var (
-		ret       interface{}
-		dataBytes []byte
-		typeBytes = false
-	)
+func typeOf(value interface{}) JSONType {
+	switch value.(type) {
+	case map[string]interface{}:
+		return JSONTypeObject
+	case []interface{}:
+		return JSONTypeArray
+	case string:
+		return JSONTypeString
+	case float64, json.Number:
+		return JSONTypeNumber
+	case bool:
+		return JSONTypeBool
+	}
+
+	return JSONTypeNull
+}
+
+func marshalValue(value interface{}, isPretty bool) ([]byte, error) {
+	if isPretty {
+		return json.MarshalIndent(value, "", "\t")
+	}
 
+	return json.Marshal(value)
+}
+
+// deepCopy returns a copy of data sharing no memory with it. []byte data is parsed as JSON.
+// Decoded JSON values, json.Number included, are copied as is; any other value is normalized
+// through a JSON round trip. With useNumber, parsed numbers are decoded as json.Number.
+func deepCopy(data interface{}, useNumber bool) (interface{}, error) {
 	switch obj := data.(type) {
 	case *bjson:
-		data = obj.value
-		return deepCopy(data)
+		return deepCopy(obj.value, useNumber)
 
 	case []byte:
-		typeBytes = true
-		dataBytes = obj
+		return unmarshalValue(obj, useNumber)
 	}
 
-	if !typeBytes {
-		var err error
-		dataBytes, err = json.Marshal(data)
-		if err != nil {
-			return nil, err
-		}
+	return copyValue(data, useNumber)
+}
+
+// unmarshalValue parses data like json.Unmarshal, decoding numbers as json.Number with
+// useNumber.
+func unmarshalValue(data []byte, useNumber bool) (interface{}, error) {
+	if useNumber {
+		return unmarshalUseNumber(data)
 	}
 
-	if err := json.Unmarshal(dataBytes, &ret); err != nil {
+	var ret interface{}
+	if err := json.Unmarshal(data, &ret); err != nil {
 		return nil, err
 	}
 
 	return ret, nil
 }
+
+func copyValue(value interface{}, useNumber bool) (interface{}, error) {
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(obj))
+		for key, child := range obj {
+			childCopy, err := copyValue(child, useNumber)
+			if err != nil {
+				return nil, err
+			}
+
+			ret[key] = childCopy
+		}
+
+		return ret, nil
+
+	case []interface{}:
+		ret := make([]interface{}, len(obj))
+		for idx, child := range obj {
+			childCopy, err := copyValue(child, useNumber)
+			if err != nil {
+				return nil, err
+			}
+
+			ret[idx] = childCopy
+		}
+
+		return ret, nil
+
+	case nil, string, bool:
+		return obj, nil
+
+	case json.Number:
+		// an invalid literal is left to the round trip below, which rejects it
+		if numberPattern.MatchString(obj.String()) {
+			return obj, nil
+		}
+
+	case float64:
+		if !math.IsNaN(obj) && !math.IsInf(obj, 0) {
+			return obj, nil
+		}
+	}
+
+	dataBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalValue(dataBytes, useNumber)
+}
@@ -2,6 +2,7 @@ package bjson
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"os"
@@ -476,6 +477,149 @@ func Test_bjson_GetElement(t *testing.T) {
 	}
 }
 
+func Test_bjson_GetClosest(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		want      string
+		wantDepth int
+		wantErr   bool
+	}{
+		{
+			name:      "success - fully resolved",
+			fields:    fields{value: `{"a":{"b":[1,2]}}`},
+			args:      args{targets: []string{"a", "b", "1"}},
+			want:      `2`,
+			wantDepth: 3,
+			wantErr:   false,
+		},
+		{
+			name:      "success - root",
+			fields:    fields{value: `{"a":1}`},
+			args:      args{targets: nil},
+			want:      `{"a":1}`,
+			wantDepth: 0,
+			wantErr:   false,
+		},
+		{
+			name:      "fail - stop at missing key",
+			fields:    fields{value: `{"a":{"b":{"x":1}}}`},
+			args:      args{targets: []string{"a", "b", "c", "d"}},
+			want:      `{"x":1}`,
+			wantDepth: 2,
+			wantErr:   true,
+		},
+		{
+			name:      "fail - stop at invalid index",
+			fields:    fields{value: `{"a":[1]}`},
+			args:      args{targets: []string{"a", "5"}},
+			want:      `[1]`,
+			wantDepth: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "fail - stop at scalar",
+			fields:    fields{value: `{"a":"x"}`},
+			args:      args{targets: []string{"a", "b"}},
+			want:      `"x"`,
+			wantDepth: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, gotDepth, err := bj.GetClosest(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got.String())
+			assert.Equal(t, tt.wantDepth, gotDepth)
+		})
+	}
+}
+
+func Test_bjson_ValueRef(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:    "success - json object",
+			fields:  fields{value: `{"a":{"b":[1,"x"]}}`},
+			args:    args{targets: []string{"a"}},
+			want:    map[string]interface{}{"b": []interface{}{float64(1), "x"}},
+			wantErr: false,
+		},
+		{
+			name:    "success - scalar",
+			fields:  fields{value: `{"a":true}`},
+			args:    args{targets: []string{"a"}},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.ValueRef(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("success - value is shared with the document", func(t *testing.T) {
+		bj, err := NewBJSON(`{"a":{"b":1}}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := bj.ValueRef("a")
+		assert.NoError(t, err)
+		assert.NoError(t, bj.SetElement(2, "a", "b"))
+		assert.Equal(t, map[string]interface{}{"b": float64(2)}, got)
+	})
+}
+
 func Test_bjson_SetElement(t *testing.T) {
 	type fields struct {
 		value interface{}
@@ -874,6 +1018,35 @@ func Test_bjson_RemoveElement(t *testing.T) {
 	}
 }
 
+func Test_bjson_RemoveElement_arrayAliasing(t *testing.T) {
+	bj, err := NewBJSON(`{"a":[1,2,3],"b":[[1,2],[3]]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view, err := bj.GetElement("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := view.Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := bj.ValueRef("b", "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, bj.RemoveElement("a", "0"))
+	assert.NoError(t, bj.RemoveElement("b", "0", "1"))
+	assert.Equal(t, `{"a":[2,3],"b":[[1],[3]]}`, bj.String())
+	assert.Equal(t, `[1,2,3]`, view.String())
+	assert.Equal(t, `[1,2,3]`, cp.String())
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, ref)
+}
+
 func Test_bjson_Marshal(t *testing.T) {
 	type fields struct {
 		value interface{}
@@ -921,6 +1094,70 @@ func Test_bjson_Marshal(t *testing.T) {
 	}
 }
 
+func Test_bjson_MarshalIndent(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		prefix  string
+		indent  string
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - two spaces",
+			fields:  fields{value: `{"a":[1,{"b":true}]}`},
+			args:    args{prefix: "", indent: "  ", targets: nil},
+			want:    "{\n  \"a\": [\n    1,\n    {\n      \"b\": true\n    }\n  ]\n}",
+			wantErr: false,
+		},
+		{
+			name:    "success - custom prefix on sub element",
+			fields:  fields{value: `{"a":{"b":[1,2]}}`},
+			args:    args{prefix: "// ", indent: "\t", targets: []string{"a"}},
+			want:    "{\n// \t\"b\": [\n// \t\t1,\n// \t\t2\n// \t]\n// }",
+			wantErr: false,
+		},
+		{
+			name:    "success - empty indent",
+			fields:  fields{value: `[1,2]`},
+			args:    args{prefix: "", indent: "", targets: nil},
+			want:    "[\n1,\n2\n]",
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{prefix: "", indent: "  ", targets: []string{"a"}},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.MarshalIndent(tt.args.prefix, tt.args.indent, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
 func Test_bjson_MarshalWrite(t *testing.T) {
 	type fields struct {
 		value interface{}
@@ -949,6 +1186,18 @@ func Test_bjson_MarshalWrite(t *testing.T) {
 			args:   args{path: path.Join(os.TempDir(), "test.json"), isPretty: true, targets: nil},
 			want:   "{\n\t\"arr\": [\n\t\t1,\n\t\t2,\n\t\t3\n\t]\n}",
 		},
+		{
+			name:   "success - marshal and write only the nested sub element",
+			fields: fields{value: `{"a":{"b":{"c":[1,2]}},"d":"skip"}`},
+			args:   args{path: path.Join(os.TempDir(), "test.json"), isPretty: true, targets: []string{"a", "b"}},
+			want:   "{\n\t\"c\": [\n\t\t1,\n\t\t2\n\t]\n}",
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{path: path.Join(os.TempDir(), "test.json"), isPretty: false, targets: []string{"b"}},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1168,6 +1417,78 @@ func Test_bjson_EscapeElement(t *testing.T) {
 	}
 }
 
+func Test_bjson_EscapedString(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - escape json object",
+			fields:  fields{value: `{"a":{"b":1,"c":"x"}}`},
+			args:    args{targets: []string{"a"}},
+			want:    `"{\"b\":1,\"c\":\"x\"}"`,
+			wantErr: false,
+		},
+		{
+			name:    "success - escape json array root",
+			fields:  fields{value: `[1,"2"]`},
+			args:    args{targets: nil},
+			want:    `"[1,\"2\"]"`,
+			wantErr: false,
+		},
+		{
+			name:    "success - escape empty string",
+			fields:  fields{value: `{"a":""}`},
+			args:    args{targets: []string{"a"}},
+			want:    `""`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    ``,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			je, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := je.String()
+			got, err := je.EscapedString(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, before, je.String())
+
+			// the result must match what EscapeElement stores
+			if !tt.wantErr {
+				assert.NoError(t, je.EscapeElement(tt.args.targets...))
+				escaped, err := je.GetElement(tt.args.targets...)
+				assert.NoError(t, err)
+				assert.Equal(t, escaped.String(), got)
+			}
+		})
+	}
+}
+
 func Test_bjson_UnescapeElement(t *testing.T) {
 	type fields struct {
 		value interface{}
@@ -1272,6 +1593,59 @@ func Test_bjson_UnescapeElement(t *testing.T) {
 	}
 }
 
+func Test_bjson_CanonicalizeEscaping(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   string
+	}{
+		{
+			name:   "success - escaped json object",
+			fields: fields{value: `"{\"arr\":[1,2]}"`},
+			want:   `{"arr":[1,2]}`,
+		},
+		{
+			name:   "success - escaped many times",
+			fields: fields{value: `"\"[\\\"x\\\"]\""`},
+			want:   `["x"]`,
+		},
+		{
+			name:   "success - json object is left untouched",
+			fields: fields{value: `{"a":"{\"b\":1}"}`},
+			want:   `{"a":"{\"b\":1}"}`,
+		},
+		{
+			name:   "success - genuine string is left untouched",
+			fields: fields{value: `"hello"`},
+			want:   `"hello"`,
+		},
+		{
+			name:   "success - string holding a number is left untouched",
+			fields: fields{value: `"123"`},
+			want:   `"123"`,
+		},
+		{
+			name:   "success - string holding a quoted string is left untouched",
+			fields: fields{value: `"\"abc\""`},
+			want:   `"\"abc\""`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.NoError(t, bj.CanonicalizeEscaping())
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
 func Test_bjson_Len(t *testing.T) {
 	type fields struct {
 		value interface{}
@@ -1292,8 +1666,28 @@ func Test_bjson_Len(t *testing.T) {
 			want:   3,
 		},
 		{
-			name:   "fail - from string",
+			name:   "success - from string",
 			fields: fields{value: `"hello"`},
+			want:   5,
+		},
+		{
+			name:   "success - from multi byte string",
+			fields: fields{value: `"héllo, 世界"`},
+			want:   9,
+		},
+		{
+			name:   "success - from number",
+			fields: fields{value: `12345`},
+			want:   0,
+		},
+		{
+			name:   "success - from bool",
+			fields: fields{value: `true`},
+			want:   0,
+		},
+		{
+			name:   "success - from null",
+			fields: fields{value: `null`},
 			want:   0,
 		},
 	}
@@ -1366,24 +1760,93 @@ func Test_bjson_Copy(t *testing.T) {
 	}
 }
 
-func Test_bjson_String(t *testing.T) {
+func Test_bjson_Adopt(t *testing.T) {
 	type fields struct {
 		value interface{}
 	}
+	type args struct {
+		other interface{}
+	}
 	tests := []struct {
-		name   string
-		fields fields
-		want   string
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
 	}{
 		{
-			name:   "success - JSON object",
-			fields: fields{value: `{"a": 1, "b": 2}`},
-			want:   `{"a":1,"b":2}`,
+			name:    "success - replace object with array",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{other: `[1,{"b":2}]`},
+			want:    `[1,{"b":2}]`,
+			wantErr: false,
 		},
 		{
-			name:   "success - JSON array",
-			fields: fields{value: `{"arr": [1, 2, 3]}`},
-			want:   `{"arr":[1,2,3]}`,
+			name:    "success - replace with scalar",
+			fields:  fields{value: `[1]`},
+			args:    args{other: `"x"`},
+			want:    `"x"`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - other is nil",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{other: nil},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var other BJSON
+			if tt.args.other != nil {
+				if other, err = NewBJSON(tt.args.other); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err = bj.Adopt(other)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.want, bj.String())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, bj.String())
+
+			// modify the adopted document and verify that the receiver remains unchanged
+			if err = other.SetElement(42); err != nil {
+				assert.FailNow(t, err.Error())
+			}
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_String(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   string
+	}{
+		{
+			name:   "success - JSON object",
+			fields: fields{value: `{"a": 1, "b": 2}`},
+			want:   `{"a":1,"b":2}`,
+		},
+		{
+			name:   "success - JSON array",
+			fields: fields{value: `{"arr": [1, 2, 3]}`},
+			want:   `{"arr":[1,2,3]}`,
 		},
 		// ... (other test cases)
 	}
@@ -1398,3 +1861,1631 @@ func Test_bjson_String(t *testing.T) {
 		})
 	}
 }
+
+func Test_bjson_TakeElement(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		want     string
+		wantRest string
+		wantErr  bool
+	}{
+		{
+			name:     "success - take json object child",
+			fields:   fields{value: `{"a":{"b":1},"c":2}`},
+			args:     args{targets: []string{"a"}},
+			want:     `{"b":1}`,
+			wantRest: `{"c":2}`,
+			wantErr:  false,
+		},
+		{
+			name:     "success - take json array child",
+			fields:   fields{value: `{"arr":[1,2,3]}`},
+			args:     args{targets: []string{"arr", "1"}},
+			want:     `2`,
+			wantRest: `{"arr":[1,3]}`,
+			wantErr:  false,
+		},
+		{
+			name:     "fail - element is not found",
+			fields:   fields{value: `{"a":1}`},
+			args:     args{targets: []string{"b"}},
+			want:     ``,
+			wantRest: `{"a":1}`,
+			wantErr:  true,
+		},
+		{
+			name:     "fail - take root",
+			fields:   fields{value: `{"a":1}`},
+			args:     args{targets: nil},
+			want:     ``,
+			wantRest: `{"a":1}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.TakeElement(tt.args.targets...)
+			assert.Equal(t, tt.wantRest, bj.String())
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func Test_bjson_Focus(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - focus json object child",
+			fields:  fields{value: `{"response":{"data":{"a":1},"meta":"x"}}`},
+			args:    args{targets: []string{"response", "data"}},
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - focus json array child",
+			fields:  fields{value: `{"arr":[{"a":1},{"b":2}]}`},
+			args:    args{targets: []string{"arr", "1"}},
+			want:    `{"b":2}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - focus root",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{targets: nil},
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{targets: []string{"b"}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.Focus(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_GetLenient(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - resolve directly",
+			fields:  fields{value: `{"a":{"b":1}}`},
+			args:    args{targets: []string{"a", "b"}},
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			name:    "success - unwrap single element json array",
+			fields:  fields{value: `{"a":[{"b":1}]}`},
+			args:    args{targets: []string{"a", "b"}},
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			name:    "success - unwrap single key json object",
+			fields:  fields{value: `{"a":{"wrapper":{"b":1}}}`},
+			args:    args{targets: []string{"a", "b"}},
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			name:    "success - unwrap nested layers",
+			fields:  fields{value: `{"a":[{"wrapper":[{"b":1}]}]}`},
+			args:    args{targets: []string{"a", "b"}},
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - json array with many elements is not unwrapped",
+			fields:  fields{value: `{"a":[{"b":1},{"b":2}]}`},
+			args:    args{targets: []string{"a", "b"}},
+			want:    ``,
+			wantErr: true,
+		},
+		{
+			name:    "fail - json object with many keys is not unwrapped",
+			fields:  fields{value: `{"a":{"x":{"b":1},"y":{"b":2}}}`},
+			args:    args{targets: []string{"a", "b"}},
+			want:    ``,
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{"a":[1]}`},
+			args:    args{targets: []string{"a", "b"}},
+			want:    ``,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.GetLenient(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func Test_bjson_ArrayIndexError(t *testing.T) {
+	type args struct {
+		fn func(bj BJSON) error
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr string
+	}{
+		{
+			name:    "fail - get out of range",
+			args:    args{fn: func(bj BJSON) error { _, err := bj.GetElement("a", "b", "3"); return err }},
+			wantErr: "index 3 out of range for array of length 2 at 'JSON[a][b]'",
+		},
+		{
+			name:    "fail - get negative index out of range",
+			args:    args{fn: func(bj BJSON) error { _, err := bj.GetElement("a", "b", "-3"); return err }},
+			wantErr: "index -3 out of range for array of length 2 at 'JSON[a][b]'",
+		},
+		{
+			name:    "fail - set out of range",
+			args:    args{fn: func(bj BJSON) error { return bj.SetElement(1, "a", "b", "2") }},
+			wantErr: "index 2 out of range for array of length 2 at 'JSON[a][b]'",
+		},
+		{
+			name:    "fail - remove out of range",
+			args:    args{fn: func(bj BJSON) error { return bj.RemoveElement("a", "b", "5") }},
+			wantErr: "index 5 out of range for array of length 2 at 'JSON[a][b]'",
+		},
+		{
+			name:    "fail - add into out of range",
+			args:    args{fn: func(bj BJSON) error { return bj.AddElement(1, "a", "b", "9", "c") }},
+			wantErr: "index 9 out of range for array of length 2 at 'JSON[a][b]'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(`{"a":{"b":[{"x":1},{"x":2}]}}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.EqualError(t, tt.args.fn(bj), tt.wantErr)
+		})
+	}
+}
+
+func Test_bjson_EscapeSizeDelta(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "success - escaping an object",
+			fields:  fields{value: `{"a":{"b":"c"}}`},
+			args:    args{targets: []string{"a"}},
+			want:    6,
+			wantErr: false,
+		},
+		{
+			name:    "success - escaping a number",
+			fields:  fields{value: `{"a":12}`},
+			args:    args{targets: []string{"a"}},
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name:    "success - escaping an empty string",
+			fields:  fields{value: `{"a":""}`},
+			args:    args{targets: []string{"a"}},
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			got, err := bj.EscapeSizeDelta(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func Test_bjson_GetTyped(t *testing.T) {
+	bj, err := NewBJSON(`{"s":"str","i":42,"neg":-7,"f":3.5,"big":1e300,"b":true,"n":null,"arr":[1,"x"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		get func() (interface{}, error)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        interface{}
+		wantTypeErr bool
+		wantErr     bool
+	}{
+		{
+			name:    "success - get string",
+			args:    args{get: func() (interface{}, error) { return bj.GetString("s") }},
+			want:    "str",
+			wantErr: false,
+		},
+		{
+			name:    "success - get int",
+			args:    args{get: func() (interface{}, error) { return bj.GetInt("i") }},
+			want:    int64(42),
+			wantErr: false,
+		},
+		{
+			name:    "success - get int in array",
+			args:    args{get: func() (interface{}, error) { return bj.GetInt("arr", "0") }},
+			want:    int64(1),
+			wantErr: false,
+		},
+		{
+			name:    "success - get float64",
+			args:    args{get: func() (interface{}, error) { return bj.GetFloat64("f") }},
+			want:    3.5,
+			wantErr: false,
+		},
+		{
+			name:    "success - get integral number as float64",
+			args:    args{get: func() (interface{}, error) { return bj.GetFloat64("neg") }},
+			want:    float64(-7),
+			wantErr: false,
+		},
+		{
+			name:    "success - get bool",
+			args:    args{get: func() (interface{}, error) { return bj.GetBool("b") }},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:    "fail - get int from fractional number",
+			args:    args{get: func() (interface{}, error) { return bj.GetInt("f") }},
+			want:    int64(0),
+			wantErr: true,
+		},
+		{
+			name:    "fail - get int out of range",
+			args:    args{get: func() (interface{}, error) { return bj.GetInt("big") }},
+			want:    int64(0),
+			wantErr: true,
+		},
+		{
+			name:        "fail - get int from string",
+			args:        args{get: func() (interface{}, error) { return bj.GetInt("s") }},
+			want:        int64(0),
+			wantTypeErr: true,
+			wantErr:     true,
+		},
+		{
+			name:        "fail - get string from number",
+			args:        args{get: func() (interface{}, error) { return bj.GetString("arr", "0") }},
+			want:        "",
+			wantTypeErr: true,
+			wantErr:     true,
+		},
+		{
+			name:        "fail - get float64 from bool",
+			args:        args{get: func() (interface{}, error) { return bj.GetFloat64("b") }},
+			want:        float64(0),
+			wantTypeErr: true,
+			wantErr:     true,
+		},
+		{
+			name:        "fail - get bool from null",
+			args:        args{get: func() (interface{}, error) { return bj.GetBool("n") }},
+			want:        false,
+			wantTypeErr: true,
+			wantErr:     true,
+		},
+		{
+			name:    "fail - get string from missing path",
+			args:    args{get: func() (interface{}, error) { return bj.GetString("missing") }},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.args.get()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var typeErr *TypeError
+			assert.Equal(t, tt.wantTypeErr, errors.As(err, &typeErr))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTypeError_Error(t *testing.T) {
+	err := &TypeError{Path: "'JSON[a]'", Want: JSONTypeNumber, Got: JSONTypeString}
+	assert.EqualError(t, err, "element 'JSON[a]' is not a json number. got: string")
+}
+
+func Test_bjson_GetArray(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		args        args
+		want        []interface{}
+		wantTypeErr bool
+		wantErr     bool
+	}{
+		{
+			name:    "success - nested array",
+			fields:  fields{value: `{"a":[1,"x",{"b":[true]}]}`},
+			args:    args{targets: []string{"a"}},
+			want:    []interface{}{float64(1), "x", map[string]interface{}{"b": []interface{}{true}}},
+			wantErr: false,
+		},
+		{
+			name:        "fail - element is an object",
+			fields:      fields{value: `{"a":{}}`},
+			args:        args{targets: []string{"a"}},
+			want:        nil,
+			wantTypeErr: true,
+			wantErr:     true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			got, err := bj.GetArray(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var typeErr *TypeError
+			assert.Equal(t, tt.wantTypeErr, errors.As(err, &typeErr))
+			assert.Equal(t, tt.want, got)
+
+			// mutate the returned array and verify that the document remains unchanged
+			for idx := range got {
+				if nested, ok := got[idx].(map[string]interface{}); ok {
+					nested["b"] = "changed"
+				}
+				got[idx] = "changed"
+			}
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func Test_bjson_GetMap(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		args        args
+		want        map[string]interface{}
+		wantTypeErr bool
+		wantErr     bool
+	}{
+		{
+			name:    "success - nested object",
+			fields:  fields{value: `[{"a":{"b":[1]},"c":null}]`},
+			args:    args{targets: []string{"0"}},
+			want:    map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{float64(1)}}, "c": nil},
+			wantErr: false,
+		},
+		{
+			name:        "fail - element is a string",
+			fields:      fields{value: `{"a":"x"}`},
+			args:        args{targets: []string{"a"}},
+			want:        nil,
+			wantTypeErr: true,
+			wantErr:     true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `[]`},
+			args:    args{targets: []string{"0"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			got, err := bj.GetMap(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var typeErr *TypeError
+			assert.Equal(t, tt.wantTypeErr, errors.As(err, &typeErr))
+			assert.Equal(t, tt.want, got)
+
+			// mutate the returned object and verify that the document remains unchanged
+			if nested, ok := got["a"].(map[string]interface{}); ok {
+				nested["b"] = "changed"
+			}
+			for key := range got {
+				got[key] = "changed"
+			}
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func Test_bjson_Exists(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   bool
+	}{
+		{
+			name:   "success - nested object key",
+			fields: fields{value: `{"a":{"b":{"c":1}}}`},
+			args:   args{targets: []string{"a", "b", "c"}},
+			want:   true,
+		},
+		{
+			name:   "success - valid array index",
+			fields: fields{value: `{"a":[1,{"b":2}]}`},
+			args:   args{targets: []string{"a", "1", "b"}},
+			want:   true,
+		},
+		{
+			name:   "success - null value exists",
+			fields: fields{value: `{"a":null}`},
+			args:   args{targets: []string{"a"}},
+			want:   true,
+		},
+		{
+			name:   "success - root",
+			fields: fields{value: `null`},
+			args:   args{targets: nil},
+			want:   true,
+		},
+		{
+			name:   "fail - missing key",
+			fields: fields{value: `{"a":{"b":1}}`},
+			args:   args{targets: []string{"a", "c"}},
+			want:   false,
+		},
+		{
+			name:   "fail - below null",
+			fields: fields{value: `{"a":null}`},
+			args:   args{targets: []string{"a", "b"}},
+			want:   false,
+		},
+		{
+			name:   "fail - out of range array index",
+			fields: fields{value: `[1,2]`},
+			args:   args{targets: []string{"2"}},
+			want:   false,
+		},
+		{
+			name:   "fail - invalid array index",
+			fields: fields{value: `[1,2]`},
+			args:   args{targets: []string{"x"}},
+			want:   false,
+		},
+		{
+			name:   "fail - below scalar",
+			fields: fields{value: `{"a":"str"}`},
+			args:   args{targets: []string{"a", "0"}},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tt.want, bj.Exists(tt.args.targets...))
+		})
+	}
+}
+
+func Test_bjson_TypeOf(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    JSONType
+		wantErr bool
+	}{
+		{
+			name:    "success - object",
+			fields:  fields{value: `{"a":{}}`},
+			args:    args{targets: []string{"a"}},
+			want:    JSONTypeObject,
+			wantErr: false,
+		},
+		{
+			name:    "success - array",
+			fields:  fields{value: `{"a":[]}`},
+			args:    args{targets: []string{"a"}},
+			want:    JSONTypeArray,
+			wantErr: false,
+		},
+		{
+			name:    "success - string",
+			fields:  fields{value: `{"a":["x"]}`},
+			args:    args{targets: []string{"a", "0"}},
+			want:    JSONTypeString,
+			wantErr: false,
+		},
+		{
+			name:    "success - number",
+			fields:  fields{value: `{"a":1.5}`},
+			args:    args{targets: []string{"a"}},
+			want:    JSONTypeNumber,
+			wantErr: false,
+		},
+		{
+			name:    "success - bool",
+			fields:  fields{value: `{"a":false}`},
+			args:    args{targets: []string{"a"}},
+			want:    JSONTypeBool,
+			wantErr: false,
+		},
+		{
+			name:    "success - null",
+			fields:  fields{value: `{"a":null}`},
+			args:    args{targets: []string{"a"}},
+			want:    JSONTypeNull,
+			wantErr: false,
+		},
+		{
+			name:    "success - root",
+			fields:  fields{value: `[]`},
+			args:    args{targets: nil},
+			want:    JSONTypeArray,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.TypeOf(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJSONType_String(t *testing.T) {
+	assert.Equal(t, "boolean", JSONTypeBool.String())
+	assert.Equal(t, "object", fmt.Sprint(JSONTypeObject))
+}
+
+func Test_bjson_Keys(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "success - root object in sorted order",
+			fields:  fields{value: `{"b":1,"a":{"z":1},"c":null}`},
+			args:    args{targets: nil},
+			want:    []string{"a", "b", "c"},
+			wantErr: false,
+		},
+		{
+			name:    "success - nested object",
+			fields:  fields{value: `{"a":[{"y":1,"x":2}]}`},
+			args:    args{targets: []string{"a", "0"}},
+			want:    []string{"x", "y"},
+			wantErr: false,
+		},
+		{
+			name:    "success - empty object",
+			fields:  fields{value: `{"a":{}}`},
+			args:    args{targets: []string{"a"}},
+			want:    []string{},
+			wantErr: false,
+		},
+		{
+			name:    "fail - array target",
+			fields:  fields{value: `{"a":[1]}`},
+			args:    args{targets: []string{"a"}},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - scalar target",
+			fields:  fields{value: `{"a":"x"}`},
+			args:    args{targets: []string{"a"}},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.Keys(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_Keys_arrayHint(t *testing.T) {
+	bj, err := NewBJSON(`{"a":[1]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = bj.Keys("a")
+	var typeErr *TypeError
+	assert.ErrorAs(t, err, &typeErr)
+	assert.Contains(t, err.Error(), "use Len")
+}
+
+func Test_bjson_MoveElement(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		from []string
+		to   []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - move to a new key of another object",
+			fields:  fields{value: `{"a":{"x":{"k":1}},"b":{}}`},
+			args:    args{from: []string{"a", "x"}, to: []string{"b", "y"}},
+			want:    `{"a":{},"b":{"y":{"k":1}}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - move replaces existing key",
+			fields:  fields{value: `{"a":1,"b":[2]}`},
+			args:    args{from: []string{"a"}, to: []string{"b"}},
+			want:    `{"b":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - reorder array forward",
+			fields:  fields{value: `["x","y","z"]`},
+			args:    args{from: []string{"0"}, to: []string{"2"}},
+			want:    `["y","z","x"]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - reorder array backward",
+			fields:  fields{value: `["x","y","z"]`},
+			args:    args{from: []string{"2"}, to: []string{"0"}},
+			want:    `["z","x","y"]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - move to negative array index",
+			fields:  fields{value: `{"arr":[1,2,3],"v":4}`},
+			args:    args{from: []string{"v"}, to: []string{"arr", "-1"}},
+			want:    `{"arr":[1,2,4,3]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - move from negative array index",
+			fields:  fields{value: `{"arr":[1,2,3],"o":{}}`},
+			args:    args{from: []string{"arr", "-1"}, to: []string{"o", "v"}},
+			want:    `{"arr":[1,2],"o":{"v":3}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - move object member into array",
+			fields:  fields{value: `{"arr":[1],"v":2}`},
+			args:    args{from: []string{"v"}, to: []string{"arr", "1"}},
+			want:    `{"arr":[1,2]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - move onto itself",
+			fields:  fields{value: `{"a":{"b":1}}`},
+			args:    args{from: []string{"a", "b"}, to: []string{"a", "b"}},
+			want:    `{"a":{"b":1}}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - parent of destination is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{from: []string{"a"}, to: []string{"b", "c"}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - move into own child rolls back",
+			fields:  fields{value: `{"a":{"b":{}}}`},
+			args:    args{from: []string{"a"}, to: []string{"a", "b", "c"}},
+			want:    `{"a":{"b":{}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - negative array index out of range rolls back",
+			fields:  fields{value: `{"arr":[1,2],"v":3}`},
+			args:    args{from: []string{"v"}, to: []string{"arr", "-3"}},
+			want:    `{"arr":[1,2],"v":3}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - array index out of range rolls back",
+			fields:  fields{value: `{"arr":[1,2],"v":3}`},
+			args:    args{from: []string{"v"}, to: []string{"arr", "5"}},
+			want:    `{"arr":[1,2],"v":3}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - source is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{from: []string{"x"}, to: []string{"b"}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.MoveElement(tt.args.from, tt.args.to)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_CopyElement(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		from []string
+		to   []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - copy to a new key",
+			fields:  fields{value: `{"a":{"x":[1]},"b":{}}`},
+			args:    args{from: []string{"a"}, to: []string{"b", "c"}},
+			want:    `{"a":{"x":[1]},"b":{"c":{"x":[1]}}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - copy appends to existing array",
+			fields:  fields{value: `{"a":"v","arr":[1]}`},
+			args:    args{from: []string{"a"}, to: []string{"arr"}},
+			want:    `{"a":"v","arr":[1,"v"]}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - key is already exist",
+			fields:  fields{value: `{"a":1,"b":2}`},
+			args:    args{from: []string{"a"}, to: []string{"b"}},
+			want:    `{"a":1,"b":2}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - source is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{from: []string{"x"}, to: []string{"b"}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - parent of destination is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{from: []string{"a"}, to: []string{"b", "c"}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.CopyElement(tt.args.from, tt.args.to)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_CopyElement_independent(t *testing.T) {
+	bj, err := NewBJSON(`{"a":{"list":[1]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, bj.CopyElement([]string{"a"}, []string{"b"}))
+	assert.NoError(t, bj.AddElement(2, "a", "list"))
+	assert.NoError(t, bj.SetElement("x", "b", "list", "0"))
+	assert.Equal(t, `{"a":{"list":[1,2]},"b":{"list":["x"]}}`, bj.String())
+}
+
+func Test_bjson_RenameKey(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		newKey  string
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - rename root key",
+			fields:  fields{value: `{"a":{"x":1},"b":2}`},
+			args:    args{newKey: "c", targets: []string{"a"}},
+			want:    `{"b":2,"c":{"x":1}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - rename nested key",
+			fields:  fields{value: `{"a":[{"old":[1]}]}`},
+			args:    args{newKey: "new", targets: []string{"a", "0", "old"}},
+			want:    `{"a":[{"new":[1]}]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - rename key to itself",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{newKey: "a", targets: []string{"a"}},
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - parent is not a json object",
+			fields:  fields{value: `{"a":[1]}`},
+			args:    args{newKey: "b", targets: []string{"a", "0"}},
+			want:    `{"a":[1]}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - old key is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{newKey: "c", targets: []string{"b"}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - new key is already exist",
+			fields:  fields{value: `{"a":1,"b":2}`},
+			args:    args{newKey: "b", targets: []string{"a"}},
+			want:    `{"a":1,"b":2}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - top level element",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{newKey: "b", targets: nil},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.RenameKey(tt.args.newKey, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_SetElementForce(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		value   interface{}
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - create three missing levels",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{value: "v", targets: []string{"x", "y", "z"}},
+			want:    `{"a":1,"x":{"y":{"z":"v"}}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - create below existing array element",
+			fields:  fields{value: `{"arr":[{}]}`},
+			args:    args{value: true, targets: []string{"arr", "0", "p", "q"}},
+			want:    `{"arr":[{"p":{"q":true}}]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - overwrite existing leaf",
+			fields:  fields{value: `{"a":{"b":1}}`},
+			args:    args{value: 2, targets: []string{"a", "b"}},
+			want:    `{"a":{"b":2}}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - intermediate is a scalar",
+			fields:  fields{value: `{"a":"str"}`},
+			args:    args{value: 1, targets: []string{"a", "b", "c"}},
+			want:    `{"a":"str"}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - intermediate array index is not found",
+			fields:  fields{value: `{"arr":[]}`},
+			args:    args{value: 1, targets: []string{"arr", "0", "b"}},
+			want:    `{"arr":[]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.SetElementForce(tt.args.value, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_appendToken(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		isAdd   bool
+		value   interface{}
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - set appends to nested array",
+			fields:  fields{value: `{"data":{"items":[1]}}`},
+			args:    args{isAdd: false, value: 2, targets: []string{"data", "items", "-"}},
+			want:    `{"data":{"items":[1,2]}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - add appends to nested array",
+			fields:  fields{value: `{"data":{"items":[[1]]}}`},
+			args:    args{isAdd: true, value: []interface{}{2}, targets: []string{"data", "items", "-"}},
+			want:    `{"data":{"items":[[1],[2]]}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - set appends to root array",
+			fields:  fields{value: `[]`},
+			args:    args{isAdd: false, value: "x", targets: []string{"-"}},
+			want:    `["x"]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - dash is an ordinary object key",
+			fields:  fields{value: `{"data":{}}`},
+			args:    args{isAdd: true, value: 1, targets: []string{"data", "-"}},
+			want:    `{"data":{"-":1}}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - parent is a scalar",
+			fields:  fields{value: `{"data":"str"}`},
+			args:    args{isAdd: false, value: 1, targets: []string{"data", "-"}},
+			want:    `{"data":"str"}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - dash is not a valid intermediate index",
+			fields:  fields{value: `{"data":[{}]}`},
+			args:    args{isAdd: false, value: 1, targets: []string{"data", "-", "a"}},
+			want:    `{"data":[{}]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.args.isAdd {
+				err = bj.AddElement(tt.args.value, tt.args.targets...)
+			} else {
+				err = bj.SetElement(tt.args.value, tt.args.targets...)
+			}
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_negativeIndex(t *testing.T) {
+	type args struct {
+		fn func(bj BJSON) (string, error)
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "success - get last element",
+			args: args{fn: func(bj BJSON) (string, error) {
+				got, err := bj.GetElement("arr", "-1")
+				if err != nil {
+					return "", err
+				}
+				return got.String(), nil
+			}},
+			want:    `"c"`,
+			wantErr: false,
+		},
+		{
+			name: "success - get second to last element",
+			args: args{fn: func(bj BJSON) (string, error) {
+				got, err := bj.GetElement("arr", "-2")
+				if err != nil {
+					return "", err
+				}
+				return got.String(), nil
+			}},
+			want:    `"b"`,
+			wantErr: false,
+		},
+		{
+			name: "success - set last element",
+			args: args{fn: func(bj BJSON) (string, error) {
+				err := bj.SetElement("z", "arr", "-1")
+				return bj.String(), err
+			}},
+			want:    `{"arr":["a","b","z"]}`,
+			wantErr: false,
+		},
+		{
+			name: "success - remove second to last element",
+			args: args{fn: func(bj BJSON) (string, error) {
+				err := bj.RemoveElement("arr", "-2")
+				return bj.String(), err
+			}},
+			want:    `{"arr":["a","c"]}`,
+			wantErr: false,
+		},
+		{
+			name: "success - exists with negative index",
+			args: args{fn: func(bj BJSON) (string, error) {
+				return fmt.Sprint(bj.Exists("arr", "-3"), bj.Exists("arr", "-4")), nil
+			}},
+			want:    `true false`,
+			wantErr: false,
+		},
+		{
+			name: "success - append token is not an index",
+			args: args{fn: func(bj BJSON) (string, error) {
+				err := bj.SetElement("d", "arr", "-")
+				return bj.String(), err
+			}},
+			want:    `{"arr":["a","b","c","d"]}`,
+			wantErr: false,
+		},
+		{
+			name: "fail - get out of range",
+			args: args{fn: func(bj BJSON) (string, error) {
+				_, err := bj.GetElement("arr", "-99")
+				return "", err
+			}},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name: "fail - set out of range",
+			args: args{fn: func(bj BJSON) (string, error) {
+				err := bj.SetElement("z", "arr", "-99")
+				return bj.String(), err
+			}},
+			want:    `{"arr":["a","b","c"]}`,
+			wantErr: true,
+		},
+		{
+			name: "fail - remove out of range",
+			args: args{fn: func(bj BJSON) (string, error) {
+				err := bj.RemoveElement("arr", "-99")
+				return bj.String(), err
+			}},
+			want:    `{"arr":["a","b","c"]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(`{"arr":["a","b","c"]}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tt.args.fn(bj)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_SetMarshalRoot(t *testing.T) {
+	bj, err := NewBJSON(`{"data":{"phone":["1"],"name":"x"},"meta":{"v":1}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Error(t, bj.SetMarshalRoot("missing"))
+	assert.Equal(t, `{"data":{"name":"x","phone":["1"]},"meta":{"v":1}}`, bj.String())
+
+	assert.NoError(t, bj.SetMarshalRoot("data"))
+	assert.Equal(t, `{"name":"x","phone":["1"]}`, bj.String())
+
+	assert.NoError(t, bj.SetElement(2, "meta", "v"))
+	assert.NoError(t, bj.AddElement("2", "data", "phone"))
+	assert.Equal(t, `{"name":"x","phone":["1","2"]}`, bj.String())
+
+	got, err := bj.Marshal(true, "phone")
+	assert.NoError(t, err)
+	assert.Equal(t, "[\n\t\"1\",\n\t\"2\"\n]", string(got))
+
+	got, err = bj.MarshalIndent("", " ", "phone")
+	assert.NoError(t, err)
+	assert.Equal(t, "[\n \"1\",\n \"2\"\n]", string(got))
+
+	_, err = bj.Marshal(false, "meta")
+	assert.Error(t, err)
+
+	var meta map[string]interface{}
+	assert.NoError(t, bj.Unmarshal(&meta, "meta"))
+	assert.Equal(t, map[string]interface{}{"v": float64(2)}, meta)
+
+	bj.ClearMarshalRoot()
+	assert.Equal(t, `{"data":{"name":"x","phone":["1","2"]},"meta":{"v":2}}`, bj.String())
+}
+
+func Test_bjson_SetMarshalRoot_followsDocument(t *testing.T) {
+	type args struct {
+		root []string
+		fn   func(bj BJSON) (BJSON, error)
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "success - focus on root clears it",
+			args: args{root: []string{"data"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.Focus("data")
+			}},
+			want:    `{"name":"x","phone":["1"]}`,
+			wantErr: false,
+		},
+		{
+			name: "success - focus on ancestor rebases root",
+			args: args{root: []string{"data", "phone"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.Focus("data")
+			}},
+			want:    `["1"]`,
+			wantErr: false,
+		},
+		{
+			name: "success - focus with negative index rebases root",
+			args: args{root: []string{"list", "1", "a"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.Focus("list", "-1")
+			}},
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			name: "success - focus outside root clears it",
+			args: args{root: []string{"data"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.Focus("meta")
+			}},
+			want:    `{"v":1}`,
+			wantErr: false,
+		},
+		{
+			name: "success - remove root clears it",
+			args: args{root: []string{"data", "phone"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.RemoveElement("data")
+			}},
+			want:    `{"list":[{},{"a":1}],"meta":{"v":1}}`,
+			wantErr: false,
+		},
+		{
+			name: "success - remove outside root keeps it",
+			args: args{root: []string{"data"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.RemoveElement("meta")
+			}},
+			want:    `{"name":"x","phone":["1"]}`,
+			wantErr: false,
+		},
+		{
+			name: "success - take root clears it",
+			args: args{root: []string{"list", "1"}, fn: func(bj BJSON) (BJSON, error) {
+				_, err := bj.TakeElement("list", "-1")
+				return bj, err
+			}},
+			want:    `{"data":{"name":"x","phone":["1"]},"list":[{}],"meta":{"v":1}}`,
+			wantErr: false,
+		},
+		{
+			name: "success - copy keeps root",
+			args: args{root: []string{"data"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj.Copy()
+			}},
+			want:    `{"name":"x","phone":["1"]}`,
+			wantErr: false,
+		},
+		{
+			name: "success - new bjson with options takes the whole document",
+			args: args{root: []string{"data"}, fn: func(bj BJSON) (BJSON, error) {
+				return NewBJSONWithOptions(bj, WithUseNumber())
+			}},
+			want:    `{"data":{"name":"x","phone":["1"]},"list":[{},{"a":1}],"meta":{"v":1}}`,
+			wantErr: false,
+		},
+		{
+			name: "success - removing an earlier index shifts root",
+			args: args{root: []string{"list", "1"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.RemoveElement("list", "0")
+			}},
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name: "success - inserting before root shifts it",
+			args: args{root: []string{"list", "1"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.MoveElement([]string{"meta"}, []string{"list", "0"})
+			}},
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name: "success - move root",
+			args: args{root: []string{"data"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.MoveElement([]string{"data"}, []string{"meta", "d"})
+			}},
+			want:    `{"name":"x","phone":["1"]}`,
+			wantErr: false,
+		},
+		{
+			name: "success - move ancestor of root to the end of a json array",
+			args: args{root: []string{"data", "phone"}, fn: func(bj BJSON) (BJSON, error) {
+				if err := bj.MoveElement([]string{"data"}, []string{"list", "-"}); err != nil {
+					return bj, err
+				}
+
+				return bj, bj.RemoveElement("list", "0")
+			}},
+			want:    `["1"]`,
+			wantErr: false,
+		},
+		{
+			name: "success - rename root key",
+			args: args{root: []string{"data", "phone"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.RenameKey("info", "data")
+			}},
+			want:    `["1"]`,
+			wantErr: false,
+		},
+		{
+			name: "success - transaction removing root clears it",
+			args: args{root: []string{"data"}, fn: func(bj BJSON) (BJSON, error) {
+				txn := bj.Begin()
+				txn.Remove("data")
+				return bj, txn.Commit()
+			}},
+			want:    `{"list":[{},{"a":1}],"meta":{"v":1}}`,
+			wantErr: false,
+		},
+		{
+			name: "success - patch removing an earlier index shifts root",
+			args: args{root: []string{"list", "1"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.ApplyPatch([]byte(`[{"op":"remove","path":"/list/0"}]`))
+			}},
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name: "success - merge removing root falls back to the whole document",
+			args: args{root: []string{"data"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.Merge(`{"data":null}`)
+			}},
+			want:    `{"list":[{},{"a":1}],"meta":{"v":1}}`,
+			wantErr: false,
+		},
+		{
+			name: "fail - failed focus keeps root",
+			args: args{root: []string{"data"}, fn: func(bj BJSON) (BJSON, error) {
+				return bj, bj.Focus("missing")
+			}},
+			want:    `{"name":"x","phone":["1"]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(`{"data":{"phone":["1"],"name":"x"},"list":[{},{"a":1}],"meta":{"v":1}}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = bj.SetMarshalRoot(tt.args.root...); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tt.args.fn(bj)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
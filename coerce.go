@@ -0,0 +1,547 @@
+package bjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// booleanTokens are the string values CoerceBooleans converts, compared case-insensitively.
+var booleanTokens = map[string]bool{
+	"true":  true,
+	"false": false,
+	"yes":   true,
+	"no":    false,
+	"on":    true,
+	"off":   false,
+	"1":     true,
+	"0":     false,
+}
+
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// CoerceBooleans converts every string leaf under targets matching one of
+// "true", "yes", "on", "1" or "false", "no", "off", "0" (case-insensitive, no trimming)
+// into the matching JSON boolean. Other strings are left untouched.
+func (bj *bjson) CoerceBooleans(targets ...string) error {
+	return bj.transformLeaves(targets, func(path []string, value interface{}) (interface{}, error) {
+		str, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+
+		if b, ok := booleanTokens[strings.ToLower(str)]; ok {
+			return b, nil
+		}
+
+		return value, nil
+	})
+}
+
+// SanitizeControlChars rewrites every string leaf under targets so it holds no control
+// characters (see unicode.IsControl), such as tabs, newlines or DEL, according to policy.
+// Keys and non-string leaves are left untouched.
+func (bj *bjson) SanitizeControlChars(policy ControlCharPolicy, targets ...string) error {
+	var replacement rune
+	switch policy {
+	case ControlCharStrip:
+		replacement = -1
+	case ControlCharSpace:
+		replacement = ' '
+	default:
+		return fmt.Errorf("invalid control character policy: %v", policy)
+	}
+
+	return bj.transformLeaves(targets, func(path []string, value interface{}) (interface{}, error) {
+		str, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+
+		return strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return replacement
+			}
+
+			return r
+		}, str), nil
+	})
+}
+
+// EmptyStringPaths returns the paths, relative to targets and in walk order, of the string
+// leaves that are empty or hold only white space.
+func (bj *bjson) EmptyStringPaths(targets ...string) ([][]string, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return nil, err
+	}
+
+	var ret [][]string
+	_ = walkElement(nil, sel.value, func(path []string, value interface{}) error {
+		if isBlankString(value) {
+			ret = append(ret, path)
+		}
+
+		return nil
+	})
+
+	return ret, nil
+}
+
+// NullifyEmptyStrings replaces every string leaf under targets reported by EmptyStringPaths
+// with null.
+func (bj *bjson) NullifyEmptyStrings(targets ...string) error {
+	return bj.transformLeaves(targets, func(path []string, value interface{}) (interface{}, error) {
+		if isBlankString(value) {
+			return nil, nil
+		}
+
+		return value, nil
+	})
+}
+
+func isBlankString(value interface{}) bool {
+	str, ok := value.(string)
+	return ok && strings.TrimSpace(str) == ""
+}
+
+// StringifyNumbers converts the numbers matched by each of paths, which may contain "*"
+// wildcards (see resolveElements), into JSON strings holding their digits, e.g. 12345 becomes
+// "12345", and json.Number values keep their exact literal. Matches that are not numbers are left
+// alone. Either every path is converted or, when any path does not resolve, none is.
+func (bj *bjson) StringifyNumbers(paths [][]string) error {
+	var nodes []pathValue
+	for _, path := range paths {
+		matches, err := bj.resolveElements(path)
+		if err != nil {
+			return err
+		}
+
+		nodes = append(nodes, matches...)
+	}
+
+	for _, node := range nodes {
+		if typeOf(node.value) != JSONTypeNumber {
+			continue
+		}
+
+		str, _ := scalarString(node.value)
+		if err := bj.SetElement(str, node.path...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DefaultNulls sets every dot-path key of defaults (see ParsePath) to a copy of its value when
+// the path holds null. When fillMissing is true, missing paths are set as well and missing
+// intermediate objects are created. Paths holding a non-null value are left alone. Either
+// every default is applied or none is.
+func (bj *bjson) DefaultNulls(defaults map[string]interface{}, fillMissing bool) error {
+	paths := make([]string, 0, len(defaults))
+	for path := range defaults {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return bj.atomic(func(scratch *bjson) error {
+		for _, path := range paths {
+			targets, err := ParsePath(path)
+			if err != nil {
+				return err
+			}
+
+			sel, err := scratch.getElement(newTracer(targets))
+			if (err == nil && sel.value != nil) || (err != nil && !fillMissing) {
+				continue
+			}
+
+			if err = scratch.updateElement(uoSetForce, defaults[path], newTracer(targets)); err != nil {
+				return fmt.Errorf("fail to set default at path '%v'. %v", path, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// InferTypes guesses the type represented by every string leaf under targets and returns
+// it keyed by the canonical dot-path relative to targets (see formatPath). A string is
+// inferred, in order, as JSONTypeNumber when it is a valid JSON number literal, JSONTypeBool
+// when it is "true" or "false" (case-insensitive), JSONTypeDate when it parses as an RFC 3339
+// timestamp and JSONTypeString otherwise. Non-string leaves are not reported.
+func (bj *bjson) InferTypes(targets ...string) (map[string]JSONType, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]JSONType)
+	_ = walkElement(nil, sel.value, func(path []string, value interface{}) error {
+		if str, ok := value.(string); ok {
+			ret[formatPath(path)] = inferType(str)
+		}
+
+		return nil
+	})
+
+	return ret, nil
+}
+
+func inferType(str string) JSONType {
+	if numberPattern.MatchString(str) {
+		return JSONTypeNumber
+	}
+
+	switch strings.ToLower(str) {
+	case "true", "false":
+		return JSONTypeBool
+	}
+
+	if _, err := time.Parse(time.RFC3339, str); err == nil {
+		return JSONTypeDate
+	}
+
+	return JSONTypeString
+}
+
+// CoerceByMap converts the leaves at the dot-path keys of types, relative to targets, into
+// the mapped type. Strings holding a JSON number literal become numbers, json.Number ones in a
+// document built WithUseNumber, strings accepted by CoerceBooleans become booleans and strings
+// become dates when they are RFC 3339 timestamps. Leaves already holding the requested type
+// are left alone; any other combination fails naming the path, in which case the document is
+// left unchanged.
+func (bj *bjson) CoerceByMap(types map[string]JSONType, targets ...string) error {
+	paths := make([]string, 0, len(types))
+	for path := range types {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return bj.atomic(func(scratch *bjson) error {
+		for _, path := range paths {
+			relTargets, err := ParsePath(path)
+			if err != nil {
+				return err
+			}
+
+			fullTargets := append(append([]string{}, targets...), relTargets...)
+			sel, err := scratch.getElement(newTracer(fullTargets))
+			if err != nil {
+				return fmt.Errorf("fail to coerce path '%v'. %v", path, err)
+			}
+
+			nVal, err := coerceValue(sel.value, types[path], scratch.useNumber)
+			if err != nil {
+				return fmt.Errorf("fail to coerce path '%v'. %v", path, err)
+			}
+
+			if err = scratch.SetElement(nVal, fullTargets...); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func coerceValue(value interface{}, typ JSONType, useNumber bool) (interface{}, error) {
+	str, isStr := value.(string)
+	switch {
+	case typ == JSONTypeDate && isStr:
+		if _, err := time.Parse(time.RFC3339, str); err == nil {
+			return value, nil
+		}
+
+	case typeOf(value) == typ:
+		return value, nil
+
+	case typ == JSONTypeNumber && isStr:
+		if numberPattern.MatchString(str) {
+			if useNumber {
+				return json.Number(str), nil
+			}
+
+			return strconv.ParseFloat(str, 64)
+		}
+
+	case typ == JSONTypeBool && isStr:
+		if b, ok := booleanTokens[strings.ToLower(str)]; ok {
+			return b, nil
+		}
+	}
+
+	if isStr {
+		return nil, fmt.Errorf("cannot coerce %q to %v", str, typ)
+	}
+
+	return nil, fmt.Errorf("cannot coerce %v to %v", typeOf(value), typ)
+}
+
+// PrecisionRisks returns the paths, relative to targets, of the numbers and number literal
+// strings that a float64 cannot hold exactly: integers beyond 2^53 and literals with more
+// significant digits than a float64 preserves. Paths are reported in walk order.
+func (bj *bjson) PrecisionRisks(targets ...string) ([][]string, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return nil, err
+	}
+
+	var ret [][]string
+	_ = walkElement(nil, sel.value, func(path []string, value interface{}) error {
+		var isRisky bool
+		switch obj := value.(type) {
+		case float64:
+			isRisky = math.Abs(obj) > maxSafeInteger && obj == math.Trunc(obj)
+		case json.Number:
+			isRisky = hasPrecisionRisk(obj.String())
+		case string:
+			isRisky = numberPattern.MatchString(obj) && hasPrecisionRisk(obj)
+		}
+
+		if isRisky {
+			ret = append(ret, path)
+		}
+
+		return nil
+	})
+
+	return ret, nil
+}
+
+const (
+	maxSafeInteger       = 1 << 53
+	maxSignificantDigits = 15
+)
+
+func hasPrecisionRisk(literal string) bool {
+	f, _, err := big.ParseFloat(literal, 10, 256, big.ToNearestEven)
+	if err != nil {
+		return false
+	}
+
+	if f.IsInt() {
+		return new(big.Float).Abs(f).Cmp(big.NewFloat(maxSafeInteger)) > 0
+	}
+
+	mantissa := literal
+	if i := strings.IndexAny(mantissa, "eE"); i >= 0 {
+		mantissa = mantissa[:i]
+	}
+
+	digits := strings.NewReplacer("-", "", ".", "").Replace(mantissa)
+	digits = strings.TrimRight(strings.TrimLeft(digits, "0"), "0")
+	return len(digits) > maxSignificantDigits
+}
+
+// Normalize cleans up the element at targets in a single bottom-up pass. For every value the
+// steps of rules run in a fixed order: trim strings, coerce numbers, coerce booleans, prune
+// nulls and finally rename keys. Renaming two keys of one JSON object into the same key fails
+// and leaves the document unchanged.
+func (bj *bjson) Normalize(rules NormalizeRules, targets ...string) error {
+	switch rules.KeyCase {
+	case KeyCaseNone, KeyCaseLower, KeyCaseUpper, KeyCaseSnake, KeyCaseCamel:
+	default:
+		return fmt.Errorf("invalid key case: %v", rules.KeyCase)
+	}
+
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return err
+	}
+
+	nVal, err := normalizeElement(sel.value, rules, bj.useNumber, targets)
+	if err != nil {
+		return err
+	}
+
+	return bj.SetElement(nVal, targets...)
+}
+
+func normalizeElement(value interface{}, rules NormalizeRules, useNumber bool, path []string) (interface{}, error) {
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(obj))
+		for _, key := range sortedKeys(obj) {
+			child, err := normalizeElement(obj[key], rules, useNumber, appendPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+
+			if rules.PruneNulls && child == nil {
+				continue
+			}
+
+			nKey := convertKeyCase(key, rules.KeyCase)
+			if _, isExist := ret[nKey]; isExist {
+				return nil, fmt.Errorf("key %v collides with another key as %v at %v", key, nKey, parseTracerPath(path))
+			}
+			ret[nKey] = child
+		}
+
+		return ret, nil
+
+	case []interface{}:
+		ret := make([]interface{}, 0, len(obj))
+		for idx, v := range obj {
+			child, err := normalizeElement(v, rules, useNumber, appendPath(path, strconv.Itoa(idx)))
+			if err != nil {
+				return nil, err
+			}
+
+			if rules.PruneNulls && child == nil {
+				continue
+			}
+			ret = append(ret, child)
+		}
+
+		return ret, nil
+
+	case string:
+		if rules.TrimStrings {
+			obj = strings.TrimSpace(obj)
+		}
+
+		// a literal a float64 cannot hold, such as 1e999, is left as a string
+		if rules.CoerceNumbers {
+			if num, err := coerceValue(obj, JSONTypeNumber, useNumber); err == nil {
+				return num, nil
+			}
+		}
+
+		if b, ok := booleanTokens[strings.ToLower(obj)]; rules.CoerceBooleans && ok {
+			return b, nil
+		}
+
+		return obj, nil
+	}
+
+	return value, nil
+}
+
+// ObjectsToArrays converts, bottom-up, every JSON object under targets whose keys are exactly
+// the integers "0" to "n-1" written without leading zeros into a JSON array ordered by key.
+// Empty objects and objects with gaps or other keys are left alone.
+func (bj *bjson) ObjectsToArrays(targets ...string) error {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return err
+	}
+
+	return bj.SetElement(objectsToArrays(sel.value), targets...)
+}
+
+func objectsToArrays(value interface{}) interface{} {
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(obj))
+		for key, child := range obj {
+			ret[key] = objectsToArrays(child)
+		}
+
+		if len(ret) == 0 {
+			return ret
+		}
+
+		arr := make([]interface{}, len(ret))
+		for idx := range arr {
+			child, isExist := ret[strconv.Itoa(idx)]
+			if !isExist {
+				return ret
+			}
+
+			arr[idx] = child
+		}
+
+		return arr
+
+	case []interface{}:
+		ret := make([]interface{}, len(obj))
+		for idx, child := range obj {
+			ret[idx] = objectsToArrays(child)
+		}
+
+		return ret
+	}
+
+	return value
+}
+
+func convertKeyCase(key string, keyCase KeyCase) string {
+	switch keyCase {
+	case KeyCaseLower:
+		return strings.ToLower(key)
+
+	case KeyCaseUpper:
+		return strings.ToUpper(key)
+
+	case KeyCaseSnake:
+		words := splitWords(key)
+		for i, word := range words {
+			words[i] = strings.ToLower(word)
+		}
+
+		return strings.Join(words, "_")
+
+	case KeyCaseCamel:
+		words := splitWords(key)
+		for i, word := range words {
+			word = strings.ToLower(word)
+			if i > 0 {
+				runes := []rune(word)
+				runes[0] = unicode.ToUpper(runes[0])
+				word = string(runes)
+			}
+			words[i] = word
+		}
+
+		return strings.Join(words, "")
+	}
+
+	return key
+}
+
+// splitWords splits key into words at '_', '-' and ' ' separators and at case changes, so
+// "userID", "user_id" and "UserId" all yield the words "user" and "id" in their own case.
+func splitWords(key string) []string {
+	var (
+		words []string
+		word  []rune
+		runes = []rune(key)
+	)
+
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == ' ' {
+			flush()
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			isNextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && isNextLower) {
+				flush()
+			}
+		}
+
+		word = append(word, r)
+	}
+	flush()
+
+	return words
+}
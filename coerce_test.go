@@ -0,0 +1,774 @@
+package bjson
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_bjson_CoerceBooleans(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - coerce boolean tokens",
+			fields:  fields{value: `{"a":"true","b":"FALSE","c":"1","d":"0","e":"Yes","f":"no","g":"on","h":"off"}`},
+			args:    args{targets: nil},
+			want:    `{"a":true,"b":false,"c":true,"d":false,"e":true,"f":false,"g":true,"h":false}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - leave other values untouched",
+			fields:  fields{value: `{"a":"maybe","b":" true","c":1,"d":null,"e":["true",{"f":"off"}]}`},
+			args:    args{targets: nil},
+			want:    `{"a":"maybe","b":" true","c":1,"d":null,"e":[true,{"f":false}]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - coerce subtree only",
+			fields:  fields{value: `{"a":"true","b":{"c":"true"}}`},
+			args:    args{targets: []string{"b"}},
+			want:    `{"a":"true","b":{"c":true}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - coerce scalar root",
+			fields:  fields{value: `"yes"`},
+			args:    args{targets: nil},
+			want:    `true`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{"a":"true"}`},
+			args:    args{targets: []string{"b"}},
+			want:    `{"a":"true"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.CoerceBooleans(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_SanitizeControlChars(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		policy  ControlCharPolicy
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - strip control characters",
+			fields:  fields{value: map[string]interface{}{"a": "x\ty\r\nz\u007f", "b": []interface{}{"\u0000ok", 1}, "c\n": "é"}},
+			args:    args{policy: ControlCharStrip, targets: nil},
+			want:    `{"a":"xyz","b":["ok",1],"c\n":"é"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - replace control characters with spaces",
+			fields:  fields{value: map[string]interface{}{"a": map[string]interface{}{"b": "line1\nline2\t!"}, "c": "\n"}},
+			args:    args{policy: ControlCharSpace, targets: []string{"a"}},
+			want:    `{"a":{"b":"line1 line2 !"},"c":"\n"}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - invalid policy",
+			fields:  fields{value: map[string]interface{}{"a": "x\ty"}},
+			args:    args{policy: "escape", targets: nil},
+			want:    `{"a":"x\ty"}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: map[string]interface{}{}},
+			args:    args{policy: ControlCharStrip, targets: []string{"a"}},
+			want:    `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.SanitizeControlChars(tt.args.policy, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_EmptyStringPaths(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:    "success - report empty and blank strings",
+			fields:  fields{value: `{"a":"","b":"  \t\n","c":"x","d":[" ",null,0,{"e":""}],"f":{}}`},
+			args:    args{targets: nil},
+			want:    [][]string{{"a"}, {"b"}, {"d", "0"}, {"d", "3", "e"}},
+			wantErr: false,
+		},
+		{
+			name:    "success - relative to targets",
+			fields:  fields{value: `{"a":{"b":""},"c":""}`},
+			args:    args{targets: []string{"a"}},
+			want:    [][]string{{"b"}},
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.EmptyStringPaths(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_NullifyEmptyStrings(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - nullify empty and blank strings",
+			fields:  fields{value: `{"a":"","b":"  ","c":"x","d":[" ",0,{"e":""}]}`},
+			args:    args{targets: nil},
+			want:    `{"a":null,"b":null,"c":"x","d":[null,0,{"e":null}]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - only under targets",
+			fields:  fields{value: `{"a":{"b":""},"c":""}`},
+			args:    args{targets: []string{"a"}},
+			want:    `{"a":{"b":null},"c":""}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.NullifyEmptyStrings(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_StringifyNumbers(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		paths [][]string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - stringify numbers at paths and wildcards",
+			fields:  fields{value: map[string]interface{}{"id": float64(1234567890123), "users": []interface{}{map[string]interface{}{"id": 1.5, "age": 3}, map[string]interface{}{"id": "x"}}, "n": 7}},
+			args:    args{paths: [][]string{{"id"}, {"users", "*", "id"}}},
+			want:    `{"id":"1234567890123","n":7,"users":[{"age":3,"id":"1.5"},{"id":"x"}]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - keep exact json number literal",
+			fields:  fields{value: map[string]interface{}{"id": json.Number("18446744073709551615")}},
+			args:    args{paths: [][]string{{"id"}}},
+			want:    `{"id":"18446744073709551615"}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - path does not resolve",
+			fields:  fields{value: map[string]interface{}{"id": 1}},
+			args:    args{paths: [][]string{{"id"}, {"missing"}}},
+			want:    `{"id":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj := &bjson{value: tt.fields.value}
+			err := bj.StringifyNumbers(tt.args.paths)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_DefaultNulls(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		defaults    map[string]interface{}
+		fillMissing bool
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - fill null leaf",
+			fields:  fields{value: `{"a":null,"b":1}`},
+			args:    args{defaults: map[string]interface{}{"a": "x", "b": 2}},
+			want:    `{"a":"x","b":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - leave missing path alone",
+			fields:  fields{value: `{"a":null}`},
+			args:    args{defaults: map[string]interface{}{"a": "x", "b.c": 1, "a.d": 2}},
+			want:    `{"a":"x"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - fill missing path with intermediate objects",
+			fields:  fields{value: `{"a":{}}`},
+			args:    args{defaults: map[string]interface{}{"a.b.c": []interface{}{1}, "d": map[string]interface{}{"e": true}}, fillMissing: true},
+			want:    `{"a":{"b":{"c":[1]}},"d":{"e":true}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - fill null inside json array",
+			fields:  fields{value: `{"arr":[null,{"a":null}]}`},
+			args:    args{defaults: map[string]interface{}{"arr[0]": 0, "arr[1].a": "x"}},
+			want:    `{"arr":[0,{"a":"x"}]}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - malformed path",
+			fields:  fields{value: `{"a":null}`},
+			args:    args{defaults: map[string]interface{}{"a": 1, "b..c": 2}, fillMissing: true},
+			want:    `{"a":null}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - intermediate element is a scalar",
+			fields:  fields{value: `{"a":null,"b":1}`},
+			args:    args{defaults: map[string]interface{}{"a": 1, "b.c": 2}, fillMissing: true},
+			want:    `{"a":null,"b":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - json array index is missing",
+			fields:  fields{value: `{"arr":[]}`},
+			args:    args{defaults: map[string]interface{}{"arr[0]": 1}, fillMissing: true},
+			want:    `{"arr":[]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.DefaultNulls(tt.args.defaults, tt.args.fillMissing)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_InferTypes(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    map[string]JSONType
+		wantErr bool
+	}{
+		{
+			name: "success - infer string leaves",
+			fields: fields{value: `{"n":"-12.5e3","b":"TRUE","d":"2023-04-05T06:07:08Z","s":"hello",` +
+				`"z":"0012","y":"2023-04-05","x":1,"arr":["1",null,{"k":"false"}]}`},
+			args: args{targets: nil},
+			want: map[string]JSONType{
+				"n":       JSONTypeNumber,
+				"b":       JSONTypeBool,
+				"d":       JSONTypeDate,
+				"s":       JSONTypeString,
+				"z":       JSONTypeString,
+				"y":       JSONTypeString,
+				"arr.0":   JSONTypeNumber,
+				"arr.2.k": JSONTypeBool,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "success - infer relative to targets",
+			fields:  fields{value: `{"a":{"b":"1"}}`},
+			args:    args{targets: []string{"a"}},
+			want:    map[string]JSONType{"b": JSONTypeNumber},
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			got, err := bj.InferTypes(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func Test_bjson_CoerceByMap(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		types   map[string]JSONType
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "success - coerce string leaves",
+			fields: fields{value: `{"n":"12.5","b":"yes","d":"2023-04-05T06:07:08Z","s":"x","arr":["1"]}`},
+			args: args{types: map[string]JSONType{
+				"n":     JSONTypeNumber,
+				"b":     JSONTypeBool,
+				"d":     JSONTypeDate,
+				"s":     JSONTypeString,
+				"arr.0": JSONTypeNumber,
+			}},
+			want:    `{"arr":[1],"b":true,"d":"2023-04-05T06:07:08Z","n":12.5,"s":"x"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - leave leaf already holding the type",
+			fields:  fields{value: `{"n":1,"b":false}`},
+			args:    args{types: map[string]JSONType{"n": JSONTypeNumber, "b": JSONTypeBool}},
+			want:    `{"b":false,"n":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - coerce relative to targets",
+			fields:  fields{value: `{"a":{"n":"2"}}`},
+			args:    args{types: map[string]JSONType{"n": JSONTypeNumber}, targets: []string{"a"}},
+			want:    `{"a":{"n":2}}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - impossible coercion",
+			fields:  fields{value: `{"n":"1","s":"abc"}`},
+			args:    args{types: map[string]JSONType{"n": JSONTypeNumber, "s": JSONTypeNumber}},
+			want:    `{"n":"1","s":"abc"}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - invalid date",
+			fields:  fields{value: `{"d":"yesterday"}`},
+			args:    args{types: map[string]JSONType{"d": JSONTypeDate}},
+			want:    `{"d":"yesterday"}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{types: map[string]JSONType{"a": JSONTypeNumber}},
+			want:    `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.CoerceByMap(tt.args.types, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_Normalize(t *testing.T) {
+	type fields struct {
+		value interface{}
+		opts  []Option
+	}
+	type args struct {
+		rules   NormalizeRules
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "success - every rule",
+			fields: fields{value: `{"userID":" 12 ","IsActive":"yes","note":null,"Tags":[" a ",null,"1"],"HTTPServer":{"max-conn":"0"}}`},
+			args: args{rules: NormalizeRules{
+				TrimStrings:    true,
+				CoerceNumbers:  true,
+				CoerceBooleans: true,
+				PruneNulls:     true,
+				KeyCase:        KeyCaseSnake,
+			}},
+			want:    `{"http_server":{"max_conn":0},"is_active":true,"tags":["a",1],"user_id":12}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - booleans without numbers",
+			fields:  fields{value: `{"a":"1","b":" true"}`},
+			args:    args{rules: NormalizeRules{CoerceBooleans: true}},
+			want:    `{"a":true,"b":" true"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - camel case keys",
+			fields:  fields{value: `{"user_name":{"first-name":"a"},"ID":1}`},
+			args:    args{rules: NormalizeRules{KeyCase: KeyCaseCamel}},
+			want:    `{"id":1,"userName":{"firstName":"a"}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - subtree only",
+			fields:  fields{value: `{"A":{"B":" x "},"C":" y "}`},
+			args:    args{rules: NormalizeRules{TrimStrings: true, KeyCase: KeyCaseLower}, targets: []string{"A"}},
+			want:    `{"A":{"b":"x"},"C":" y "}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - out of range number stays a string",
+			fields:  fields{value: `{"a":"1e999","b":"2"}`},
+			args:    args{rules: NormalizeRules{CoerceNumbers: true}},
+			want:    `{"a":"1e999","b":2}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - numbers keep their literal with use number",
+			fields:  fields{value: `{"a":"9007199254740993","b":["1e999"]}`, opts: []Option{WithUseNumber()}},
+			args:    args{rules: NormalizeRules{CoerceNumbers: true}},
+			want:    `{"a":9007199254740993,"b":[1e999]}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - key collision",
+			fields:  fields{value: `{"userId":1,"user_id":2}`},
+			args:    args{rules: NormalizeRules{KeyCase: KeyCaseSnake}},
+			want:    `{"userId":1,"user_id":2}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - invalid key case",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{rules: NormalizeRules{KeyCase: "kebab"}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSONWithOptions(tt.fields.value, tt.fields.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.Normalize(tt.args.rules, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_PrecisionRisks(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name: "success - report risky numbers and numeric strings",
+			fields: fields{value: map[string]interface{}{
+				"id":     "12345678901234567890",
+				"safe":   "9007199254740992",
+				"big":    "9007199254740993",
+				"pi":     "3.14159265358979323846",
+				"short":  "0.000123",
+				"exp":    "1e300",
+				"num":    float64(1 << 60),
+				"small":  float64(42),
+				"frac":   1.5,
+				"jn":     json.Number("18446744073709551615"),
+				"jnSafe": json.Number("-12.5"),
+				"text":   "hello",
+				"arr":    []interface{}{"1", "-99999999999999999"},
+			}},
+			args: args{targets: nil},
+			want: [][]string{
+				{"arr", "1"},
+				{"big"},
+				{"exp"},
+				{"id"},
+				{"jn"},
+				{"num"},
+				{"pi"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "success - relative to targets",
+			fields:  fields{value: map[string]interface{}{"a": map[string]interface{}{"b": "123456789012345678"}}},
+			args:    args{targets: []string{"a"}},
+			want:    [][]string{{"b"}},
+			wantErr: false,
+		},
+		{
+			name:    "success - nothing risky",
+			fields:  fields{value: map[string]interface{}{"a": "1.25", "b": float64(7)}},
+			args:    args{targets: nil},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: map[string]interface{}{}},
+			args:    args{targets: []string{"a"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj := &bjson{value: tt.fields.value}
+			got, err := bj.PrecisionRisks(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_ObjectsToArrays(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - convert contiguous numeric keys",
+			fields:  fields{value: `{"a":{"1":"y","0":"x","2":{"0":true}},"b":[{"0":1}]}`},
+			args:    args{targets: nil},
+			want:    `{"a":["x","y",[true]],"b":[[1]]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - leave gaps, leading zeros, other keys and empty objects",
+			fields:  fields{value: `{"gap":{"0":1,"2":2},"zero":{"00":1},"mixed":{"0":1,"x":2},"start":{"1":1},"empty":{}}`},
+			args:    args{targets: nil},
+			want:    `{"empty":{},"gap":{"0":1,"2":2},"mixed":{"0":1,"x":2},"start":{"1":1},"zero":{"00":1}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - convert root of target",
+			fields:  fields{value: `{"a":{"0":"x"}}`},
+			args:    args{targets: []string{"a"}},
+			want:    `{"a":["x"]}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.ObjectsToArrays(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
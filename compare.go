@@ -0,0 +1,362 @@
+package bjson
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// Equal reports whether the document is structurally equal to other: objects regardless of
+// key order, arrays element by element in order and numbers by exact value, so 1 equals 1.0
+// and json.Number values are not rounded through float64.
+func (bj *bjson) Equal(other BJSON) bool {
+	return bj.EqualApprox(other, 0)
+}
+
+// EqualApprox reports whether the document is structurally equal to other, treating two
+// numbers as equal when their absolute difference is at most epsilon.
+func (bj *bjson) EqualApprox(other BJSON, epsilon float64) bool {
+	otherVal, err := valueOf(other)
+	if err != nil {
+		return false
+	}
+
+	return valuesEqualApprox(bj.value, otherVal, epsilon)
+}
+
+// RoundTripStable reports whether marshaling the document and parsing the result again, with
+// the number decoding the document was built with, yields a structurally equal document.
+// Documents that cannot be marshaled or parsed back are not stable.
+func (bj *bjson) RoundTripStable() bool {
+	data, err := marshalValue(bj.value, false)
+	if err != nil {
+		return false
+	}
+
+	otherVal, err := unmarshalValue(data, bj.useNumber)
+	if err != nil {
+		return false
+	}
+
+	return valuesEqual(bj.value, otherVal)
+}
+
+// ChangedPaths returns the paths present in both the document and previous whose values
+// differ, in walk order. JSON objects and arrays are compared key by key and index by index;
+// keys and indices present on one side only are ignored. Numbers are compared by value.
+func (bj *bjson) ChangedPaths(previous BJSON) ([][]string, error) {
+	prevVal, err := valueOf(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret [][]string
+	changedPaths(nil, prevVal, bj.value, func(path []string) {
+		ret = append(ret, path)
+	})
+
+	return ret, nil
+}
+
+func changedPaths(path []string, prev, curr interface{}, report func(path []string)) {
+	switch objCurr := curr.(type) {
+	case map[string]interface{}:
+		if objPrev, ok := prev.(map[string]interface{}); ok {
+			for _, key := range sortedKeys(objCurr) {
+				if childPrev, isExist := objPrev[key]; isExist {
+					changedPaths(appendPath(path, key), childPrev, objCurr[key], report)
+				}
+			}
+
+			return
+		}
+
+	case []interface{}:
+		if objPrev, ok := prev.([]interface{}); ok {
+			for idx := 0; idx < len(objCurr) && idx < len(objPrev); idx++ {
+				changedPaths(appendPath(path, strconv.Itoa(idx)), objPrev[idx], objCurr[idx], report)
+			}
+
+			return
+		}
+	}
+
+	if !valuesEqual(prev, curr) {
+		report(path)
+	}
+}
+
+// Diff returns the changes turning the document into other. JSON objects are compared key by
+// key, JSON arrays index by index and anything else by value, numbers included; a change of
+// type is reported as modified. Changes are ordered depth-first with object keys sorted and
+// array indices ascending. Old and New share memory with the documents.
+func (bj *bjson) Diff(other BJSON) ([]Change, error) {
+	otherVal, err := valueOf(other)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []Change
+	diffElement(nil, bj.value, otherVal, false, func(path []string, op ChangeOp, oldVal, newVal interface{}) {
+		change := Change{Path: path, Op: op}
+		if op != ChangeAdded {
+			change.Old = &bjson{value: oldVal, useNumber: bj.useNumber}
+		}
+		if op != ChangeRemoved {
+			change.New = &bjson{value: newVal, useNumber: bj.useNumber}
+		}
+
+		ret = append(ret, change)
+	})
+
+	return ret, nil
+}
+
+type diffFunc func(path []string, op ChangeOp, oldVal, newVal interface{})
+
+// diffElement reports the changes turning oldVal into newVal. With reverseRemovals the removed
+// trailing elements of an array are reported from the last one, so they can be removed in order.
+func diffElement(path []string, oldVal, newVal interface{}, reverseRemovals bool, report diffFunc) {
+	switch objOld := oldVal.(type) {
+	case map[string]interface{}:
+		objNew, ok := newVal.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := sortedKeys(objOld)
+		for key := range objNew {
+			if _, isExist := objOld[key]; !isExist {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			childOld, isOldExist := objOld[key]
+			childNew, isNewExist := objNew[key]
+			switch {
+			case !isNewExist:
+				report(appendPath(path, key), ChangeRemoved, childOld, nil)
+			case !isOldExist:
+				report(appendPath(path, key), ChangeAdded, nil, childNew)
+			default:
+				diffElement(appendPath(path, key), childOld, childNew, reverseRemovals, report)
+			}
+		}
+
+		return
+
+	case []interface{}:
+		objNew, ok := newVal.([]interface{})
+		if !ok {
+			break
+		}
+
+		idx := 0
+		for ; idx < len(objOld) && idx < len(objNew); idx++ {
+			diffElement(appendPath(path, strconv.Itoa(idx)), objOld[idx], objNew[idx], reverseRemovals, report)
+		}
+
+		for i := idx; i < len(objNew); i++ {
+			report(appendPath(path, strconv.Itoa(i)), ChangeAdded, nil, objNew[i])
+		}
+
+		for i := idx; i < len(objOld); i++ {
+			removed := i
+			if reverseRemovals {
+				removed = len(objOld) - 1 - (i - idx)
+			}
+
+			report(appendPath(path, strconv.Itoa(removed)), ChangeRemoved, objOld[removed], nil)
+		}
+
+		return
+	}
+
+	if !valuesEqual(oldVal, newVal) {
+		report(path, ChangeModified, oldVal, newVal)
+	}
+}
+
+// IsSubsetOf reports whether the document is contained in other. A JSON object is contained
+// when every key exists in the other object with a contained value, extra keys being allowed.
+// A JSON array is contained when the other array has the same length and every element is
+// contained in the element at the same index. Scalars must be equal, numbers by value.
+func (bj *bjson) IsSubsetOf(other BJSON) bool {
+	otherVal, err := valueOf(other)
+	if err != nil {
+		return false
+	}
+
+	return isSubset(bj.value, otherVal)
+}
+
+func isSubset(a, b interface{}) bool {
+	switch objA := a.(type) {
+	case map[string]interface{}:
+		objB, ok := b.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		for key, childA := range objA {
+			childB, ok := objB[key]
+			if !ok || !isSubset(childA, childB) {
+				return false
+			}
+		}
+
+		return true
+
+	case []interface{}:
+		objB, ok := b.([]interface{})
+		if !ok || len(objA) != len(objB) {
+			return false
+		}
+
+		for idx := range objA {
+			if !isSubset(objA[idx], objB[idx]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return valuesEqual(a, b)
+}
+
+// DistinctValues returns the distinct elements matched by targets, which may contain "*"
+// wildcards (see resolveElements), in first-seen order using structural equality.
+func (bj *bjson) DistinctValues(targets ...string) ([]BJSON, error) {
+	nodes, err := bj.resolveElements(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []BJSON{}
+	var seen []interface{}
+	for _, node := range nodes {
+		if containsValue(seen, node.value) {
+			continue
+		}
+
+		seen = append(seen, node.value)
+		ret = append(ret, &bjson{value: node.value, useNumber: bj.useNumber})
+	}
+
+	return ret, nil
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if valuesEqual(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func valuesEqual(a, b interface{}) bool {
+	return valuesEqualApprox(a, b, 0)
+}
+
+func valuesEqualApprox(a, b interface{}, epsilon float64) bool {
+	switch objA := a.(type) {
+	case map[string]interface{}:
+		objB, ok := b.(map[string]interface{})
+		if !ok || len(objA) != len(objB) {
+			return false
+		}
+
+		for key, childA := range objA {
+			childB, ok := objB[key]
+			if !ok || !valuesEqualApprox(childA, childB, epsilon) {
+				return false
+			}
+		}
+
+		return true
+
+	case []interface{}:
+		objB, ok := b.([]interface{})
+		if !ok || len(objA) != len(objB) {
+			return false
+		}
+
+		for idx := range objA {
+			if !valuesEqualApprox(objA[idx], objB[idx], epsilon) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	numA, isNumA := a.(json.Number)
+	numB, isNumB := b.(json.Number)
+	if epsilon == 0 && isNumA && isNumB {
+		if isEqual, ok := numbersEqual(numA, numB); ok {
+			return isEqual
+		}
+	}
+
+	if numA, ok := toFloat64(a); ok {
+		numB, ok := toFloat64(b)
+		return ok && (numA == numB || math.Abs(numA-numB) <= epsilon)
+	}
+
+	return a == b
+}
+
+// numbersEqual compares two json.Number values exactly, so neither is rounded through
+// float64. It reports false as ok when either literal cannot be parsed.
+func numbersEqual(a, b json.Number) (isEqual bool, ok bool) {
+	// the precision tells apart any two decimal literals of these lengths
+	prec := uint(4*(len(a)+len(b))) + 64
+	numA, _, err := big.ParseFloat(a.String(), 10, prec, big.ToNearestEven)
+	if err != nil {
+		return false, false
+	}
+
+	numB, _, err := big.ParseFloat(b.String(), 10, prec, big.ToNearestEven)
+	if err != nil {
+		return false, false
+	}
+
+	return numA.Cmp(numB) == 0, true
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch obj := v.(type) {
+	case float64:
+		return obj, true
+
+	case json.Number:
+		f, err := obj.Float64()
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func valueOf(bj BJSON) (interface{}, error) {
+	switch obj := bj.(type) {
+	case nil:
+		return nil, errors.New("bjson is nil")
+	case *bjson:
+		return obj.value, nil
+	}
+
+	data, err := bj.Marshal(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return deepCopy(data, false)
+}
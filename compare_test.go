@@ -0,0 +1,542 @@
+package bjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func Test_bjson_Equal(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		other interface{}
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   bool
+	}{
+		{
+			name:   "success - reordered object keys",
+			fields: fields{value: `{"a":1,"b":{"c":true,"d":null}}`},
+			args:   args{other: `{"b":{"d":null,"c":true},"a":1}`},
+			want:   true,
+		},
+		{
+			name:   "success - integer and float forms",
+			fields: fields{value: `{"a":1,"b":[2.50]}`},
+			args:   args{other: `{"a":1.0,"b":[2.5]}`},
+			want:   true,
+		},
+		{
+			name:   "success - nested mixture",
+			fields: fields{value: `[{"x":[1,{"y":"z"}]},null,"s",false]`},
+			args:   args{other: `[{"x":[1e0,{"y":"z"}]},null,"s",false]`},
+			want:   true,
+		},
+		{
+			name:   "fail - array order matters",
+			fields: fields{value: `[1,2]`},
+			args:   args{other: `[2,1]`},
+			want:   false,
+		},
+		{
+			name:   "fail - null and missing key",
+			fields: fields{value: `{"a":null}`},
+			args:   args{other: `{}`},
+			want:   false,
+		},
+		{
+			name:   "fail - null and false",
+			fields: fields{value: `[null]`},
+			args:   args{other: `[false]`},
+			want:   false,
+		},
+		{
+			name:   "fail - nil other",
+			fields: fields{value: `{}`},
+			args:   args{other: nil},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var other BJSON
+			if tt.args.other != nil {
+				other, err = NewBJSON(tt.args.other)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			before := bj.String()
+			assert.Equal(t, tt.want, bj.Equal(other))
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func Test_bjson_EqualApprox(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		other   interface{}
+		epsilon float64
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   bool
+	}{
+		{
+			name:   "success - numbers within epsilon",
+			fields: fields{value: `{"a":[0.1,{"b":1.0000001}],"c":"x"}`},
+			args:   args{other: `{"c":"x","a":[0.10000002,{"b":1}]}`, epsilon: 1e-6},
+			want:   true,
+		},
+		{
+			name:   "success - exact comparison with zero epsilon",
+			fields: fields{value: `{"a":1}`},
+			args:   args{other: `{"a":1.0}`, epsilon: 0},
+			want:   true,
+		},
+		{
+			name:   "fail - numbers outside epsilon",
+			fields: fields{value: `{"a":1.1}`},
+			args:   args{other: `{"a":1.0}`, epsilon: 0.01},
+			want:   false,
+		},
+		{
+			name:   "fail - different structure",
+			fields: fields{value: `{"a":[1,2]}`},
+			args:   args{other: `{"a":[1,2,3]}`, epsilon: 1},
+			want:   false,
+		},
+		{
+			name:   "fail - different scalar types",
+			fields: fields{value: `{"a":"1"}`},
+			args:   args{other: `{"a":1}`, epsilon: 1},
+			want:   false,
+		},
+		{
+			name:   "fail - nil other",
+			fields: fields{value: `{}`},
+			args:   args{other: nil, epsilon: 1},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var other BJSON
+			if tt.args.other != nil {
+				other, err = NewBJSON(tt.args.other)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			assert.Equal(t, tt.want, bj.EqualApprox(other, tt.args.epsilon))
+		})
+	}
+}
+
+func Test_bjson_DistinctValues(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "success - distinct values in json array",
+			fields:  fields{value: `{"users":[{"role":"admin"},{"role":"user"},{"role":"admin"},{"name":"x"}]}`},
+			args:    args{targets: []string{"users", "*", "role"}},
+			want:    []string{`"admin"`, `"user"`},
+			wantErr: false,
+		},
+		{
+			name:    "success - distinct values in json object",
+			fields:  fields{value: `{"users":{"b":{"tags":[1,2]},"a":{"tags":[1,2.0]},"c":{"tags":[2]}}}`},
+			args:    args{targets: []string{"users", "*", "tags"}},
+			want:    []string{`[1,2]`, `[2]`},
+			wantErr: false,
+		},
+		{
+			name:    "success - nested wildcards",
+			fields:  fields{value: `[[1,2],[2,3]]`},
+			args:    args{targets: []string{"*", "*"}},
+			want:    []string{`1`, `2`, `3`},
+			wantErr: false,
+		},
+		{
+			name:    "success - no match",
+			fields:  fields{value: `{"users":[]}`},
+			args:    args{targets: []string{"users", "*", "role"}},
+			want:    []string{},
+			wantErr: false,
+		},
+		{
+			name:    "fail - prefix is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"users", "*", "role"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.DistinctValues(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			gotStr := make([]string, 0, len(got))
+			for _, v := range got {
+				gotStr = append(gotStr, v.String())
+			}
+			assert.Equal(t, tt.want, gotStr)
+		})
+	}
+}
+
+func Test_bjson_IsSubsetOf(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		other interface{}
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   bool
+	}{
+		{
+			name:   "success - json object with extra keys",
+			fields: fields{value: `{"a":1,"b":{"c":"x"}}`},
+			args:   args{other: `{"a":1.0,"b":{"c":"x","d":2},"e":true}`},
+			want:   true,
+		},
+		{
+			name:   "success - json array elements contained by index",
+			fields: fields{value: `[{"id":1},{"id":2}]`},
+			args:   args{other: `[{"id":1,"n":"a"},{"id":2,"n":"b"}]`},
+			want:   true,
+		},
+		{
+			name:   "success - equal scalars",
+			fields: fields{value: `null`},
+			args:   args{other: `null`},
+			want:   true,
+		},
+		{
+			name:   "fail - missing key",
+			fields: fields{value: `{"a":1,"z":1}`},
+			args:   args{other: `{"a":1}`},
+			want:   false,
+		},
+		{
+			name:   "fail - different value",
+			fields: fields{value: `{"a":{"b":1}}`},
+			args:   args{other: `{"a":{"b":2}}`},
+			want:   false,
+		},
+		{
+			name:   "fail - json array length differs",
+			fields: fields{value: `[1]`},
+			args:   args{other: `[1,2]`},
+			want:   false,
+		},
+		{
+			name:   "fail - json array order differs",
+			fields: fields{value: `[2,1]`},
+			args:   args{other: `[1,2]`},
+			want:   false,
+		},
+		{
+			name:   "fail - different type",
+			fields: fields{value: `{"a":{}}`},
+			args:   args{other: `{"a":[]}`},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			other, err := NewBJSON(tt.args.other)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tt.want, bj.IsSubsetOf(other))
+		})
+	}
+}
+
+func Test_bjson_RoundTripStable(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   bool
+	}{
+		{
+			name: "success - parsed document",
+			fields: fields{value: func() interface{} {
+				bj, err := NewBJSON(`{"a":[1,2.5,"<x>",null,true],"b":{"c":"\u00e9"}}`)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return bj.(*bjson).value
+			}()},
+			want: true,
+		},
+		{
+			name:   "success - json number",
+			fields: fields{value: map[string]interface{}{"a": json.Number("1.50")}},
+			want:   true,
+		},
+		{
+			name:   "fail - json number out of float64 range",
+			fields: fields{value: map[string]interface{}{"a": json.Number("1e400")}},
+			want:   false,
+		},
+		{
+			name:   "fail - cannot marshal",
+			fields: fields{value: map[string]interface{}{"a": math.NaN()}},
+			want:   false,
+		},
+		{
+			name:   "fail - value changes type",
+			fields: fields{value: map[string]interface{}{"a": 1}},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj := &bjson{value: tt.fields.value}
+			assert.Equal(t, tt.want, bj.RoundTripStable())
+		})
+	}
+}
+
+func Test_bjson_ChangedPaths(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		previous interface{}
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:   "success - report changed leaves only",
+			fields: fields{value: `{"a":1,"b":{"c":"new","d":true},"e":[1,2,3],"added":1}`},
+			args:   args{previous: `{"a":1.0,"b":{"c":"old","d":true},"e":[1,5],"removed":1}`},
+			want: [][]string{
+				{"b", "c"},
+				{"e", "1"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "success - type change is reported at its path",
+			fields:  fields{value: `{"a":{"x":1},"b":[1]}`},
+			args:    args{previous: `{"a":[1],"b":null}`},
+			want:    [][]string{{"a"}, {"b"}},
+			wantErr: false,
+		},
+		{
+			name:    "success - root scalar changed",
+			fields:  fields{value: `1`},
+			args:    args{previous: `2`},
+			want:    [][]string{nil},
+			wantErr: false,
+		},
+		{
+			name:    "success - no changes",
+			fields:  fields{value: `{"a":[1,{"b":2}]}`},
+			args:    args{previous: `{"a":[1,{"b":2}]}`},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "fail - previous is nil",
+			fields:  fields{value: `{}`},
+			args:    args{previous: nil},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var previous BJSON
+			if tt.args.previous != nil {
+				if previous, err = NewBJSON(tt.args.previous); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := bj.ChangedPaths(previous)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_Diff(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		other interface{}
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "success - added and removed keys",
+			fields:  fields{value: `{"a":1,"c":{"x":true}}`},
+			args:    args{other: `{"b":[1],"c":{"y":null}}`},
+			want:    []string{"removed a 1 ", "added b  [1]", "removed c.x true ", "added c.y  null"},
+			wantErr: false,
+		},
+		{
+			name:    "success - modified scalars",
+			fields:  fields{value: `{"a":1,"b":"x","c":2}`},
+			args:    args{other: `{"a":1.0,"b":"y","c":"2"}`},
+			want:    []string{`modified b "x" "y"`, `modified c 2 "2"`},
+			wantErr: false,
+		},
+		{
+			name:   "success - nested container changes",
+			fields: fields{value: `{"list":[{"id":1},{"id":2}],"obj":{"k":[1]},"t":{"a":1}}`},
+			args:   args{other: `{"list":[{"id":1,"n":"x"}],"obj":{"k":[1,2,3]},"t":[1]}`},
+			want: []string{
+				`added list.0.n  "x"`,
+				`removed list.1 {"id":2} `,
+				"added obj.k.1  2",
+				"added obj.k.2  3",
+				`modified t {"a":1} [1]`,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "success - equal documents",
+			fields:  fields{value: `{"a":[1,{"b":null}]}`},
+			args:    args{other: `{"a":[1,{"b":null}]}`},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "success - modified root",
+			fields:  fields{value: `1`},
+			args:    args{other: `[]`},
+			want:    []string{"modified  1 []"},
+			wantErr: false,
+		},
+		{
+			name:    "fail - nil other",
+			fields:  fields{value: `{}`},
+			args:    args{other: nil},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var other BJSON
+			if tt.args.other != nil {
+				other, err = NewBJSON(tt.args.other)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			changes, err := bj.Diff(other)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var got []string
+			for _, change := range changes {
+				var oldStr, newStr string
+				if change.Old != nil {
+					oldStr = change.Old.String()
+				}
+				if change.New != nil {
+					newStr = change.New.String()
+				}
+
+				got = append(got, fmt.Sprintf("%v %v %v %v", change.Op, formatPath(change.Path), oldStr, newStr))
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package bjson
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+type CSVOptions struct {
+	// NestedAsJSON writes JSON object and JSON array cells as compact JSON instead of failing.
+	NestedAsJSON bool
+
+	// InferTypes converts imported cells holding a JSON number literal or "true"/"false"
+	// (case-insensitive) into numbers and booleans. Other cells stay strings.
+	InferTypes bool
+}
+
+func NewBJSONFromCSV(data []byte, header bool) (BJSON, error) {
+	return NewBJSONFromCSVWithOptions(data, header, CSVOptions{})
+}
+
+// NewBJSONFromCSVWithOptions parses RFC 4180 CSV, comma-delimited with double-quote quoting,
+// into a JSON array holding one JSON object per record. With header the first record names
+// the keys, which must be unique; otherwise the keys are col0, col1, and so on. Every record
+// must have the same number of fields.
+func NewBJSONFromCSVWithOptions(data []byte, header bool, opts CSVOptions) (BJSON, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("fail to read csv. %v", err)
+	}
+
+	var keys []string
+	if header && len(records) > 0 {
+		keys, records = records[0], records[1:]
+		seen := make(map[string]struct{}, len(keys))
+		for _, key := range keys {
+			if _, ok := seen[key]; ok {
+				return nil, fmt.Errorf("duplicate csv header: %v", key)
+			}
+			seen[key] = struct{}{}
+		}
+	} else if len(records) > 0 {
+		for col := range records[0] {
+			keys = append(keys, "col"+strconv.Itoa(col))
+		}
+	}
+
+	ret := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		obj := make(map[string]interface{}, len(keys))
+		for col, cell := range record {
+			obj[keys[col]] = csvValue(cell, opts)
+		}
+		ret = append(ret, obj)
+	}
+
+	return &bjson{value: ret}, nil
+}
+
+func csvValue(cell string, opts CSVOptions) interface{} {
+	if !opts.InferTypes {
+		return cell
+	}
+
+	switch typ := inferType(cell); typ {
+	case JSONTypeNumber, JSONTypeBool:
+		if value, err := coerceValue(cell, typ, false); err == nil {
+			return value
+		}
+	}
+
+	return cell
+}
+
+func (bj *bjson) ToCSV(targets ...string) ([]byte, error) {
+	return bj.ToCSVWithOptions(CSVOptions{}, targets...)
+}
+
+// ToCSVWithOptions converts the JSON array of JSON objects at targets into CSV. The header is
+// the sorted union of every object key and every element produces one row. Missing keys and
+// null values produce empty cells.
+func (bj *bjson) ToCSVWithOptions(opts CSVOptions, targets ...string) ([]byte, error) {
+	arr, err := bj.getArrayElement(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	keySet := make(map[string]struct{})
+	for idx, v := range arr {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("element %v is not a json object. got: %T", parseTracerPath(appendPath(targets, strconv.Itoa(idx))), v)
+		}
+
+		for key := range obj {
+			keySet[key] = struct{}{}
+		}
+	}
+
+	header := make([]string, 0, len(keySet))
+	for key := range keySet {
+		header = append(header, key)
+	}
+	sort.Strings(header)
+
+	buff := bytes.NewBuffer(nil)
+	cw := csv.NewWriter(buff)
+	if err = cw.Write(header); err != nil {
+		return nil, err
+	}
+
+	for idx, v := range arr {
+		obj := v.(map[string]interface{})
+		row := make([]string, len(header))
+		for col, key := range header {
+			row[col], err = csvCell(obj[key], opts)
+			if err != nil {
+				return nil, fmt.Errorf("fail to convert %v to csv. %v", parseTracerPath(appendPath(targets, strconv.Itoa(idx), key)), err)
+			}
+		}
+
+		if err = cw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	cw.Flush()
+	if err = cw.Error(); err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
+
+func csvCell(value interface{}, opts CSVOptions) (string, error) {
+	switch value.(type) {
+	case nil:
+		return "", nil
+
+	case map[string]interface{}, []interface{}:
+		if !opts.NestedAsJSON {
+			return "", fmt.Errorf("nested %v is not allowed", typeOf(value))
+		}
+
+		data, err := json.Marshal(value)
+		return string(data), err
+	}
+
+	str, _ := scalarString(value)
+	return str, nil
+}
@@ -0,0 +1,165 @@
+package bjson
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_bjson_ToCSVWithOptions(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		opts    CSVOptions
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - flat records",
+			fields:  fields{value: `{"rows":[{"id":1,"name":"a, b","ok":true},{"id":2.5,"extra":null},{}]}`},
+			args:    args{targets: []string{"rows"}},
+			want:    "extra,id,name,ok\n,1,\"a, b\",true\n,2.5,,\n,,,\n",
+			wantErr: false,
+		},
+		{
+			name:    "success - empty json array",
+			fields:  fields{value: `[]`},
+			args:    args{targets: nil},
+			want:    "\n",
+			wantErr: false,
+		},
+		{
+			name:    "success - nested values as json",
+			fields:  fields{value: `[{"a":[1,2],"b":{"c":"d"}}]`},
+			args:    args{opts: CSVOptions{NestedAsJSON: true}, targets: nil},
+			want:    "a,b\n\"[1,2]\",\"{\"\"c\"\":\"\"d\"\"}\"\n",
+			wantErr: false,
+		},
+		{
+			name:    "fail - nested values",
+			fields:  fields{value: `[{"a":[1,2]}]`},
+			args:    args{targets: nil},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - non object element",
+			fields:  fields{value: `[{"a":1},2]`},
+			args:    args{targets: nil},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - target is not a json array",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{targets: nil},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.ToCSVWithOptions(tt.args.opts, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, string(got))
+
+			if tt.args.opts == (CSVOptions{}) {
+				gotDefault, err := bj.ToCSV(tt.args.targets...)
+				assert.Equal(t, tt.wantErr, err != nil)
+				assert.Equal(t, tt.want, string(gotDefault))
+			}
+		})
+	}
+}
+
+func TestNewBJSONFromCSVWithOptions(t *testing.T) {
+	type args struct {
+		data   string
+		header bool
+		opts   CSVOptions
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - with header",
+			args:    args{data: "id,name\n1,\"a, b\"\n2,\"say \"\"hi\"\"\"\n", header: true},
+			want:    `[{"id":"1","name":"a, b"},{"id":"2","name":"say \"hi\""}]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - without header",
+			args:    args{data: "1,x\n2,y", header: false},
+			want:    `[{"col0":"1","col1":"x"},{"col0":"2","col1":"y"}]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - infer types",
+			args:    args{data: "n,b,s,e\n-1.5,TRUE,007,\n", header: true, opts: CSVOptions{InferTypes: true}},
+			want:    `[{"b":true,"e":"","n":-1.5,"s":"007"}]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - header only",
+			args:    args{data: "a,b\n", header: true},
+			want:    `[]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - empty input",
+			args:    args{data: "", header: true},
+			want:    `[]`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - uneven records",
+			args:    args{data: "a,b\n1\n", header: true},
+			want:    ``,
+			wantErr: true,
+		},
+		{
+			name:    "fail - duplicate header",
+			args:    args{data: "a,a\n1,2\n", header: true},
+			want:    ``,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBJSONFromCSVWithOptions([]byte(tt.args.data), tt.args.header, tt.args.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+
+			if tt.args.opts == (CSVOptions{}) {
+				gotDefault, err := NewBJSONFromCSV([]byte(tt.args.data), tt.args.header)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, gotDefault.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,498 @@
+package bjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	truncationMarker = "…"
+	diffContext      = 3
+)
+
+// MarshalMaxDepth marshals the element at targets like Marshal, but every JSON object or
+// array nested deeper than depth is rendered as the string "…". The selected element is
+// at depth 0. The document itself is not modified.
+func (bj *bjson) MarshalMaxDepth(depth int, isPretty bool, targets ...string) ([]byte, error) {
+	if depth < 0 {
+		return nil, fmt.Errorf("invalid max depth: %v", depth)
+	}
+
+	sel, err := bj.getMarshalElement(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalValue(truncateElement(sel.value, 0, depth), isPretty)
+}
+
+func truncateElement(value interface{}, depth, maxDepth int) interface{} {
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		if depth > maxDepth {
+			return truncationMarker
+		}
+
+		ret := make(map[string]interface{}, len(obj))
+		for key, child := range obj {
+			ret[key] = truncateElement(child, depth+1, maxDepth)
+		}
+
+		return ret
+
+	case []interface{}:
+		if depth > maxDepth {
+			return truncationMarker
+		}
+
+		ret := make([]interface{}, len(obj))
+		for idx, child := range obj {
+			ret[idx] = truncateElement(child, depth+1, maxDepth)
+		}
+
+		return ret
+	}
+
+	return value
+}
+
+// Project marshals a new JSON object mapping every key of paths to the element at its
+// targets. Keys whose targets do not resolve are omitted, or fail when failOnMissing is true.
+func (bj *bjson) Project(paths map[string][]string, failOnMissing, isPretty bool) ([]byte, error) {
+	keys := make([]string, 0, len(paths))
+	for key := range paths {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ret := make(map[string]interface{}, len(paths))
+	for _, key := range keys {
+		sel, err := bj.getElement(newTracer(paths[key]))
+		if err != nil {
+			if failOnMissing {
+				return nil, fmt.Errorf("fail to project key %v. %v", key, err)
+			}
+			continue
+		}
+
+		ret[key] = sel.value
+	}
+
+	return marshalValue(ret, isPretty)
+}
+
+// MarshalOption configures MarshalWithOptions.
+type MarshalOption func(*marshalOptions)
+
+type marshalOptions struct {
+	sortKeys bool
+	prefix   string
+	indent   string
+}
+
+// WithSortKeys makes MarshalWithOptions write the keys of every JSON object in sorted order
+// itself instead of relying on the order chosen by encoding/json.
+func WithSortKeys(enabled bool) MarshalOption {
+	return func(o *marshalOptions) {
+		o.sortKeys = enabled
+	}
+}
+
+// WithIndent makes MarshalWithOptions indent its output like json.MarshalIndent.
+func WithIndent(prefix, indent string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.prefix, o.indent = prefix, indent
+	}
+}
+
+// MarshalWithOptions marshals the element at targets, compact unless configured otherwise by
+// opts. When opts conflict, the last one wins.
+func (bj *bjson) MarshalWithOptions(targets []string, opts ...MarshalOption) ([]byte, error) {
+	o := &marshalOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	sel, err := bj.getMarshalElement(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if o.sortKeys {
+		var buf bytes.Buffer
+		if err = writeSortedJSON(&buf, sel.value); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+	} else if data, err = json.Marshal(sel.value); err != nil {
+		return nil, err
+	}
+
+	if o.prefix == "" && o.indent == "" {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err = json.Indent(&buf, data, o.prefix, o.indent); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeSortedJSON(buf *bytes.Buffer, value interface{}) error {
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		buf.WriteByte('{')
+		for idx, key := range sortedKeys(obj) {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+
+			keyData, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			buf.Write(keyData)
+			buf.WriteByte(':')
+
+			if err = writeSortedJSON(buf, obj[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	case []interface{}:
+		buf.WriteByte('[')
+		for idx, child := range obj {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := writeSortedJSON(buf, child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	default:
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+
+	return nil
+}
+
+// GitFriendlyString renders the element at targets as JSON with sorted keys, two-space
+// indentation and a trailing newline, so equal documents always produce identical text.
+func (bj *bjson) GitFriendlyString(targets ...string) (string, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(sel.value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data) + "\n", nil
+}
+
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// DiffText renders a unified diff, with three lines of context, from the GitFriendlyString
+// form of the document to that of other. Lines only in the document are prefixed with "-"
+// and lines only in other with "+". Equal documents yield an empty string.
+func (bj *bjson) DiffText(other BJSON) (string, error) {
+	if other == nil {
+		return "", errors.New("bjson is nil")
+	}
+
+	a, err := bj.GitFriendlyString()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := other.GitFriendlyString()
+	if err != nil {
+		return "", err
+	}
+
+	ops := diffLines(strings.Split(strings.TrimSuffix(a, "\n"), "\n"), strings.Split(strings.TrimSuffix(b, "\n"), "\n"))
+	var changes []int
+	for idx, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, idx)
+		}
+	}
+
+	if len(changes) == 0 {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- a\n+++ b\n")
+	for i := 0; i < len(changes); {
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*diffContext {
+			j++
+		}
+
+		start, end := changes[i]-diffContext, changes[j]+diffContext+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		writeDiffHunk(&sb, ops, start, end)
+		i = j + 1
+	}
+
+	return sb.String(), nil
+}
+
+// diffLines returns the edit script turning a into b using a longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ret []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ret = append(ret, diffOp{kind: ' ', text: a[i]})
+			i++
+			j++
+		case j >= len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ret = append(ret, diffOp{kind: '-', text: a[i]})
+			i++
+		default:
+			ret = append(ret, diffOp{kind: '+', text: b[j]})
+			j++
+		}
+	}
+
+	return ret
+}
+
+func writeDiffHunk(sb *strings.Builder, ops []diffOp, start, end int) {
+	aStart, bStart := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			aStart++
+		}
+		if op.kind != '-' {
+			bStart++
+		}
+	}
+
+	var aLen, bLen int
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			aLen++
+		}
+		if op.kind != '-' {
+			bLen++
+		}
+	}
+
+	// an empty range is reported at the line before it
+	if aLen == 0 {
+		aStart--
+	}
+	if bLen == 0 {
+		bStart--
+	}
+
+	sb.WriteString(fmt.Sprintf("@@ -%v,%v +%v,%v @@\n", aStart, aLen, bStart, bLen))
+	for _, op := range ops[start:end] {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.text)
+		sb.WriteByte('\n')
+	}
+}
+
+// GoLiteral renders the element at targets as Go source building the same value with
+// map[string]interface{} and []interface{} literals. Keys are sorted and numbers are written
+// as float64 conversions, matching what NewBJSON decodes, so the output is stable.
+func (bj *bjson) GoLiteral(targets ...string) (string, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err = writeGoLiteral(&sb, sel.value, 0); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+func writeGoLiteral(sb *strings.Builder, value interface{}, depth int) error {
+	indent := strings.Repeat("\t", depth+1)
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		sb.WriteString("map[string]interface{}{")
+		if len(obj) == 0 {
+			sb.WriteString("}")
+			return nil
+		}
+
+		sb.WriteString("\n")
+		for _, key := range sortedKeys(obj) {
+			sb.WriteString(indent + strconv.Quote(key) + ": ")
+			if err := writeGoLiteral(sb, obj[key], depth+1); err != nil {
+				return err
+			}
+			sb.WriteString(",\n")
+		}
+		sb.WriteString(indent[1:] + "}")
+
+	case []interface{}:
+		sb.WriteString("[]interface{}{")
+		if len(obj) == 0 {
+			sb.WriteString("}")
+			return nil
+		}
+
+		sb.WriteString("\n")
+		for _, child := range obj {
+			sb.WriteString(indent)
+			if err := writeGoLiteral(sb, child, depth+1); err != nil {
+				return err
+			}
+			sb.WriteString(",\n")
+		}
+		sb.WriteString(indent[1:] + "}")
+
+	case string:
+		sb.WriteString(strconv.Quote(obj))
+
+	case float64:
+		sb.WriteString("float64(" + strconv.FormatFloat(obj, 'g', -1, 64) + ")")
+
+	case json.Number:
+		sb.WriteString("json.Number(" + strconv.Quote(obj.String()) + ")")
+
+	case bool:
+		sb.WriteString(strconv.FormatBool(obj))
+
+	case nil:
+		sb.WriteString("nil")
+
+	default:
+		return fmt.Errorf("cannot render element with type %T as go literal", obj)
+	}
+
+	return nil
+}
+
+// Tree renders the element at targets as an indented tree similar to the tree command, with
+// object keys (sorted) and array indices as branches and leaf values inline.
+func (bj *bjson) Tree(targets ...string) (string, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("JSON")
+	if err = writeTreeNode(&sb, sel.value, ""); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+func writeTreeNode(sb *strings.Builder, value interface{}, prefix string) error {
+	leaf, isLeaf, err := treeLeaf(value)
+	if err != nil {
+		return err
+	}
+
+	if isLeaf {
+		sb.WriteString(": " + leaf)
+		return nil
+	}
+
+	var labels []string
+	var children []interface{}
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(obj) {
+			labels = append(labels, key)
+			children = append(children, obj[key])
+		}
+
+	case []interface{}:
+		for idx, child := range obj {
+			labels = append(labels, "["+strconv.Itoa(idx)+"]")
+			children = append(children, child)
+		}
+	}
+
+	for idx, child := range children {
+		connector, childPrefix := "├── ", prefix+"│   "
+		if idx == len(children)-1 {
+			connector, childPrefix = "└── ", prefix+"    "
+		}
+
+		sb.WriteString("\n" + prefix + connector + labels[idx])
+		if err = writeTreeNode(sb, child, childPrefix); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func treeLeaf(value interface{}) (string, bool, error) {
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		return "{}", len(obj) == 0, nil
+
+	case []interface{}:
+		return "[]", len(obj) == 0, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(data), true, nil
+}
@@ -0,0 +1,543 @@
+package bjson
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_bjson_MarshalMaxDepth(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		depth    int
+		isPretty bool
+		targets  []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - truncate nested containers",
+			fields:  fields{value: `{"a":{"b":{"c":1}},"d":[1,[2]],"e":"x"}`},
+			args:    args{depth: 1, isPretty: false, targets: nil},
+			want:    `{"a":{"b":"…"},"d":[1,"…"],"e":"x"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - truncate every child container",
+			fields:  fields{value: `{"a":{"b":1},"c":[],"d":2}`},
+			args:    args{depth: 0, isPretty: false, targets: nil},
+			want:    `{"a":"…","c":"…","d":2}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - depth larger than the document",
+			fields:  fields{value: `{"a":{"b":1}}`},
+			args:    args{depth: 10, isPretty: true, targets: nil},
+			want:    "{\n\t\"a\": {\n\t\t\"b\": 1\n\t}\n}",
+			wantErr: false,
+		},
+		{
+			name:    "success - truncate subtree",
+			fields:  fields{value: `{"a":{"b":{"c":1}}}`},
+			args:    args{depth: 0, isPretty: false, targets: []string{"a"}},
+			want:    `{"b":"…"}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - negative depth",
+			fields:  fields{value: `{}`},
+			args:    args{depth: -1, isPretty: false, targets: nil},
+			want:    ``,
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{depth: 1, isPretty: false, targets: []string{"a"}},
+			want:    ``,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			got, err := bj.MarshalMaxDepth(tt.args.depth, tt.args.isPretty, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func Test_bjson_Project(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		paths         map[string][]string
+		failOnMissing bool
+		isPretty      bool
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "success - project many paths",
+			fields: fields{value: `{"user":{"id":1,"name":"a"},"req":{"path":"/x","headers":{"h":"v"}}}`},
+			args: args{paths: map[string][]string{
+				"user_id": {"user", "id"},
+				"path":    {"req", "path"},
+				"headers": {"req", "headers"},
+			}},
+			want: `{"headers":{"h":"v"},"path":"/x","user_id":1}`,
+		},
+		{
+			name:   "success - omit missing paths",
+			fields: fields{value: `{"a":1}`},
+			args: args{paths: map[string][]string{
+				"a": {"a"},
+				"b": {"b"},
+			}, isPretty: true},
+			want: "{\n\t\"a\": 1\n}",
+		},
+		{
+			name:   "success - fail on missing with every path found",
+			fields: fields{value: `{"a":1,"b":{"c":[true]}}`},
+			args: args{paths: map[string][]string{
+				"a": {"a"},
+				"c": {"b", "c", "0"},
+			}, failOnMissing: true},
+			want: `{"a":1,"c":true}`,
+		},
+		{
+			name:   "fail - missing path with fail on missing",
+			fields: fields{value: `{"a":1}`},
+			args: args{paths: map[string][]string{
+				"a": {"a"},
+				"b": {"b"},
+			}, failOnMissing: true},
+			wantErr: true,
+		},
+		{
+			name:   "success - no paths",
+			fields: fields{value: `{"a":1}`},
+			args:   args{paths: nil},
+			want:   `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.Project(tt.args.paths, tt.args.failOnMissing, tt.args.isPretty)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func Test_bjson_GitFriendlyString(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - nested document",
+			fields:  fields{value: `{"b":{"d":[1,{"f":true,"e":null}],"c":"x"},"a":{}}`},
+			args:    args{targets: nil},
+			want:    "{\n  \"a\": {},\n  \"b\": {\n    \"c\": \"x\",\n    \"d\": [\n      1,\n      {\n        \"e\": null,\n        \"f\": true\n      }\n    ]\n  }\n}\n",
+			wantErr: false,
+		},
+		{
+			name:    "success - scalar subtree",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{targets: []string{"a"}},
+			want:    "1\n",
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.GitFriendlyString(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_DiffText(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		other interface{}
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "success - changed value",
+			fields: fields{value: `{"b":1,"a":"x"}`},
+			args:   args{other: `{"a":"y","b":1}`},
+			want: "--- a\n+++ b\n" +
+				"@@ -1,4 +1,4 @@\n" +
+				" {\n" +
+				"-  \"a\": \"x\",\n" +
+				"+  \"a\": \"y\",\n" +
+				"   \"b\": 1\n" +
+				" }\n",
+			wantErr: false,
+		},
+		{
+			name:   "success - separate hunks",
+			fields: fields{value: `{"a":1,"b":2,"c":3,"d":4,"e":5,"f":6,"g":7,"h":8,"i":9,"j":10}`},
+			args:   args{other: `{"a":0,"b":2,"c":3,"d":4,"e":5,"f":6,"g":7,"h":8,"i":9}`},
+			want: "--- a\n+++ b\n" +
+				"@@ -1,5 +1,5 @@\n" +
+				" {\n" +
+				"-  \"a\": 1,\n" +
+				"+  \"a\": 0,\n" +
+				"   \"b\": 2,\n" +
+				"   \"c\": 3,\n" +
+				"   \"d\": 4,\n" +
+				"@@ -7,6 +7,5 @@\n" +
+				"   \"f\": 6,\n" +
+				"   \"g\": 7,\n" +
+				"   \"h\": 8,\n" +
+				"-  \"i\": 9,\n" +
+				"-  \"j\": 10\n" +
+				"+  \"i\": 9\n" +
+				" }\n",
+			wantErr: false,
+		},
+		{
+			name:   "success - added to empty",
+			fields: fields{value: `[]`},
+			args:   args{other: `[1]`},
+			want: "--- a\n+++ b\n" +
+				"@@ -1,1 +1,3 @@\n" +
+				"-[]\n" +
+				"+[\n" +
+				"+  1\n" +
+				"+]\n",
+			wantErr: false,
+		},
+		{
+			name:    "success - equal documents",
+			fields:  fields{value: `{"a":[1,2],"b":{}}`},
+			args:    args{other: `{"b":{},"a":[1,2]}`},
+			want:    "",
+			wantErr: false,
+		},
+		{
+			name:    "fail - other is nil",
+			fields:  fields{value: `{}`},
+			args:    args{other: nil},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var other BJSON
+			if tt.args.other != nil {
+				if other, err = NewBJSON(tt.args.other); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := bj.DiffText(other)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_GoLiteral(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "success - nested document",
+			fields: fields{value: `{"b":[1.5,"x\"y",true,null,[]],"a":{},"c":{"d":1e21}}`},
+			args:   args{targets: nil},
+			want: "map[string]interface{}{\n" +
+				"\t\"a\": map[string]interface{}{},\n" +
+				"\t\"b\": []interface{}{\n" +
+				"\t\tfloat64(1.5),\n" +
+				"\t\t\"x\\\"y\",\n" +
+				"\t\ttrue,\n" +
+				"\t\tnil,\n" +
+				"\t\t[]interface{}{},\n" +
+				"\t},\n" +
+				"\t\"c\": map[string]interface{}{\n" +
+				"\t\t\"d\": float64(1e+21),\n" +
+				"\t},\n" +
+				"}",
+			wantErr: false,
+		},
+		{
+			name:    "success - scalar subtree",
+			fields:  fields{value: `{"a":"text"}`},
+			args:    args{targets: []string{"a"}},
+			want:    `"text"`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    ``,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.GoLiteral(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_Tree(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "success - nested document",
+			fields: fields{value: `{"b":{"c":[1,{"d":null}],"e":{}},"a":"x"}`},
+			args:   args{targets: nil},
+			want: "JSON\n" +
+				"├── a: \"x\"\n" +
+				"└── b\n" +
+				"    ├── c\n" +
+				"    │   ├── [0]: 1\n" +
+				"    │   └── [1]\n" +
+				"    │       └── d: null\n" +
+				"    └── e: {}",
+			wantErr: false,
+		},
+		{
+			name:    "success - scalar root",
+			fields:  fields{value: `true`},
+			args:    args{targets: nil},
+			want:    "JSON: true",
+			wantErr: false,
+		},
+		{
+			name:    "success - subtree",
+			fields:  fields{value: `{"a":[[]]}`},
+			args:    args{targets: []string{"a"}},
+			want:    "JSON\n└── [0]: []",
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    ``,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.Tree(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_MarshalWithOptions(t *testing.T) {
+	nested := `{"z":{"y":[{"d":1,"c":{"b":2,"a":3}}],"x":null},"b":"<&>","a":[true,{"k2":0,"k1":1.5}]}`
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+		opts    []MarshalOption
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - without options",
+			fields:  fields{value: nested},
+			args:    args{targets: nil, opts: nil},
+			want:    `{"a":[true,{"k1":1.5,"k2":0}],"b":"\u003c\u0026\u003e","z":{"x":null,"y":[{"c":{"a":3,"b":2},"d":1}]}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - sort keys",
+			fields:  fields{value: nested},
+			args:    args{targets: nil, opts: []MarshalOption{WithSortKeys(true)}},
+			want:    `{"a":[true,{"k1":1.5,"k2":0}],"b":"\u003c\u0026\u003e","z":{"x":null,"y":[{"c":{"a":3,"b":2},"d":1}]}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - sort keys disabled by last option",
+			fields:  fields{value: `{"b":1,"a":2}`},
+			args:    args{targets: nil, opts: []MarshalOption{WithSortKeys(true), WithSortKeys(false)}},
+			want:    `{"a":2,"b":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - sort keys with indent on sub element",
+			fields:  fields{value: nested},
+			args:    args{targets: []string{"z", "y", "0"}, opts: []MarshalOption{WithSortKeys(true), WithIndent("", "  ")}},
+			want:    "{\n  \"c\": {\n    \"a\": 3,\n    \"b\": 2\n  },\n  \"d\": 1\n}",
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: nested},
+			args:    args{targets: []string{"missing"}, opts: []MarshalOption{WithSortKeys(true)}},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.MarshalWithOptions(tt.args.targets, tt.args.opts...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func Test_bjson_MarshalWithOptions_sortKeysMatchesMarshal(t *testing.T) {
+	bj, err := NewBJSONWithOptions(`{"n":{"m":[12345678901234567890,{"q":1,"p":2}]},"ключ":"значение","a":{}}`, WithUseNumber())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := bj.Marshal(true)
+	assert.NoError(t, err)
+
+	got, err := bj.MarshalWithOptions(nil, WithSortKeys(true), WithIndent("", "\t"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
@@ -3,29 +3,137 @@ package bjson
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"unicode/utf8"
 )
 
 type bjson struct {
 	value interface{}
+
+	// marshalRoot prefixes the targets of Marshal and its variants. See SetMarshalRoot.
+	marshalRoot []string
+
+	// useNumber decodes every number parsed into the document as json.Number. See WithUseNumber.
+	useNumber bool
 }
 
 type BJSON interface {
 	AddElement(value interface{}, targets ...string) error
 	GetElement(targets ...string) (BJSON, error)
+	Exists(targets ...string) bool
+	TypeOf(targets ...string) (JSONType, error)
+	GetElementPath(path string) (BJSON, error)
+	GetFlexible(path string, sep ...rune) (BJSON, error)
+	GetPointer(pointer string) (BJSON, error)
+	GetLenient(targets ...string) (BJSON, error)
+	GetClosest(targets ...string) (BJSON, int, error)
+	ValueRef(targets ...string) (interface{}, error)
+	GetString(targets ...string) (string, error)
+	GetInt(targets ...string) (int64, error)
+	GetFloat64(targets ...string) (float64, error)
+	GetBool(targets ...string) (bool, error)
+	GetArray(targets ...string) ([]interface{}, error)
+	GetMap(targets ...string) (map[string]interface{}, error)
+	Keys(targets ...string) ([]string, error)
+	Extract(paths [][]string) (BJSON, error)
+	DistinctValues(targets ...string) ([]BJSON, error)
 	SetElement(value interface{}, targets ...string) error
+	SetElementForce(value interface{}, targets ...string) error
+	SetElementPath(value interface{}, path string) error
+	AddElementPath(value interface{}, path string) error
 	RemoveElement(targets ...string) error
+	RemoveElementPath(path string) error
+	TakeElement(targets ...string) (BJSON, error)
+	MoveElement(from []string, to []string) error
+	CopyElement(from []string, to []string) error
+	RenameKey(newKey string, targets ...string) error
+	SetPointer(value interface{}, pointer string) error
+	SetPointers(ops map[string]interface{}) error
+	RemovePointer(pointer string) error
+	Focus(targets ...string) error
+	Detach(targets ...string) (string, BJSON, error)
+	KeepOnly(paths [][]string) error
+	UpdateAll(targets []string, fn func(current BJSON) (interface{}, error)) error
+	ConflictingPaths(paths [][]string) ([][]string, error)
+	MergeArrayBy(key string, other []interface{}, targets ...string) error
+	Merge(patch interface{}) error
+	DeepMerge(other interface{}, opts ...MergeOption) error
+	MergeWithResolver(other BJSON, resolve func(path []string, a, b BJSON) (interface{}, error), targets ...string) error
+	ArrayIndicesWhere(pred func(index int, value BJSON) bool, targets ...string) ([]int, error)
+	IndexArrayBy(key string, keepLast bool, targets ...string) (BJSON, error)
+	AlignArrayObjects(fill interface{}, targets ...string) error
+	ConcatAll(targets ...string) (BJSON, error)
+	DiscoverKey(targets ...string) (string, error)
+	SortArraysCanonical(targets ...string) error
+	ArrayDuplicates(targets ...string) ([]int, error)
+	ToCSV(targets ...string) ([]byte, error)
+	ToCSVWithOptions(opts CSVOptions, targets ...string) ([]byte, error)
 
+	SetMarshalRoot(targets ...string) error
+	ClearMarshalRoot()
 	Marshal(isPretty bool, targets ...string) ([]byte, error)
+	MarshalIndent(prefix, indent string, targets ...string) ([]byte, error)
+	MarshalWithOptions(targets []string, opts ...MarshalOption) ([]byte, error)
+	MarshalMaxDepth(depth int, isPretty bool, targets ...string) ([]byte, error)
+	Project(paths map[string][]string, failOnMissing, isPretty bool) ([]byte, error)
+	GoLiteral(targets ...string) (string, error)
+	Tree(targets ...string) (string, error)
+	GitFriendlyString(targets ...string) (string, error)
+	DiffText(other BJSON) (string, error)
 	MarshalWrite(path string, isPretty bool, targets ...string) error
+	MarshalToProgress(w io.Writer, isPretty bool, onBytes func(written int64), targets ...string) error
 	Unmarshal(v any, targets ...string) error
 
 	EscapeElement(targets ...string) error
+	EscapedString(targets ...string) (string, error)
+	EscapeSizeDelta(targets ...string) (int, error)
 	UnescapeElement(targets ...string) error
+	CanonicalizeEscaping() error
+
+	CoerceBooleans(targets ...string) error
+	StringifyNumbers(paths [][]string) error
+	SanitizeControlChars(policy ControlCharPolicy, targets ...string) error
+	EmptyStringPaths(targets ...string) ([][]string, error)
+	NullifyEmptyStrings(targets ...string) error
+	DefaultNulls(defaults map[string]interface{}, fillMissing bool) error
+	InferTypes(targets ...string) (map[string]JSONType, error)
+	CoerceByMap(types map[string]JSONType, targets ...string) error
+	Normalize(rules NormalizeRules, targets ...string) error
+	ObjectsToArrays(targets ...string) error
+	PrecisionRisks(targets ...string) ([][]string, error)
+	Schema(targets ...string) (BJSON, error)
 
 	Len() int
+	NodeCount(targets ...string) (int, error)
+	Index() (map[string]BJSON, error)
+	KeyDepths(name string) (map[int]int, error)
+	Walk(fn func(path []string, value BJSON) error) error
+	GetAllPaths(includeEmpty bool) [][]string
+	Flatten(delimiter string) (map[string]interface{}, error)
+	FindKey(key string) [][]string
+	FindValue(value interface{}) [][]string
+	ForEach(fn func(key string, value BJSON) error, targets ...string) error
+	Where(pred func(path []string, value BJSON) bool) ([]Match, error)
+	InternStrings() int
 	Copy() (BJSON, error)
+	Adopt(other BJSON) error
+	Begin() Txn
+	Atomic(fn func(scratch BJSON) error) error
+	Equal(other BJSON) bool
+	EqualApprox(other BJSON, epsilon float64) bool
+	RoundTripStable() bool
+	ChangedPaths(previous BJSON) ([][]string, error)
+	Diff(other BJSON) ([]Change, error)
+	GeneratePatch(target BJSON) ([]byte, error)
+	ApplyPatch(patch []byte) error
+	CheckRanges(rules map[string][2]float64) ([]string, error)
+	RequireNonEmpty(paths [][]string) ([]string, error)
+	MatchesStruct(v interface{}) ([]string, error)
+	IsSubsetOf(other BJSON) bool
 	String() string
 }
 
@@ -35,14 +143,63 @@ func NewBJSON(data interface{}) (BJSON, error) {
 		data = []byte(dataString)
 	}
 
-	bjValue, err := deepCopy(data)
+	bjValue, err := deepCopy(data, false)
 	if err != nil {
+		if dataBytes, ok := data.([]byte); ok {
+			return nil, decodeError(dataBytes, err)
+		}
+
 		return nil, err
 	}
 
 	return &bjson{value: bjValue}, nil
 }
 
+// NewBJSONWithOptions builds a document like NewBJSON, configured by opts.
+func NewBJSONWithOptions(data interface{}, opts ...Option) (BJSON, error) {
+	o := newOptions(opts)
+
+	var dataBytes []byte
+	switch obj := data.(type) {
+	case string:
+		dataBytes = []byte(obj)
+	case []byte:
+		dataBytes = obj
+	case BJSON:
+		// the raw value is used so a marshal root of obj does not cut the document down
+		value, err := valueOf(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		if dataBytes, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	default:
+		var err error
+		if dataBytes, err = json.Marshal(obj); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.maxKeys > 0 {
+		if err := checkMaxKeys(dataBytes, o.maxKeys); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.useNumber {
+		bjValue, err := unmarshalUseNumber(dataBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		return &bjson{value: bjValue, useNumber: true}, nil
+	}
+
+	return NewBJSON(dataBytes)
+}
+
 func NewBJSONFromFile(path string) (BJSON, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -52,6 +209,38 @@ func NewBJSONFromFile(path string) (BJSON, error) {
 	return NewBJSON(data)
 }
 
+func NewBJSONFromReader(r io.Reader) (BJSON, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading data: %w", err)
+	}
+
+	return NewBJSON(data)
+}
+
+// decodeError adds the 1-based line and column of the offending character to a syntax error
+// returned while decoding data. Other errors are returned as is.
+func decodeError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return err
+	}
+
+	// the offset points past the offending character, or at the end of truncated input
+	pos := int(syntaxErr.Offset) - 1
+	if strings.HasPrefix(syntaxErr.Error(), "unexpected end") || pos > len(data) {
+		pos = len(data)
+	}
+	if pos < 0 {
+		pos = 0
+	}
+
+	lineStart := bytes.LastIndexByte(data[:pos], '\n') + 1
+	line := bytes.Count(data[:pos], []byte{'\n'}) + 1
+	column := utf8.RuneCount(data[lineStart:pos]) + 1
+	return fmt.Errorf("invalid JSON at line %v, column %v: %w", line, column, err)
+}
+
 func MarshalWrite(path string, v interface{}, isPretty bool) error {
 	data, err := json.Marshal(v)
 	if err != nil {
@@ -1,11 +1,16 @@
 package bjson
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestNewBJSON(t *testing.T) {
@@ -126,6 +131,18 @@ func TestNewBJSON(t *testing.T) {
 			want:    "",
 			wantErr: true,
 		},
+		{
+			name:    "success - from map with json number",
+			args:    args{data: map[string]interface{}{"a": json.Number("1.50")}},
+			want:    `{"a":1.50}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - from map with invalid json number",
+			args:    args{data: map[string]interface{}{"a": json.Number("abc")}},
+			want:    "",
+			wantErr: true,
+		},
 		{
 			name:    "success - from escaped json",
 			args:    args{data: `"{\"arr\":[1,2,3]}"`},
@@ -148,6 +165,447 @@ func TestNewBJSON(t *testing.T) {
 	}
 }
 
+func TestNewBJSON_syntaxErrorPosition(t *testing.T) {
+	type args struct {
+		data interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr string
+	}{
+		{
+			name:    "fail - invalid first character",
+			args:    args{data: "asd"},
+			wantErr: "invalid JSON at line 1, column 1: invalid character 'a' looking for beginning of value",
+		},
+		{
+			name:    "fail - invalid value on a later line",
+			args:    args{data: []byte("{\n  \"a\": 1,\n  \"b\": x\n}")},
+			wantErr: "invalid JSON at line 3, column 8: invalid character 'x' looking for beginning of value",
+		},
+		{
+			name:    "fail - column counts characters",
+			args:    args{data: `{"é": tru}`},
+			wantErr: "invalid JSON at line 1, column 10: invalid character '}' in literal true (expecting 'e')",
+		},
+		{
+			name:    "fail - truncated input",
+			args:    args{data: "{\"a\":"},
+			wantErr: "invalid JSON at line 1, column 6: unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBJSON(tt.args.data)
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, got)
+		})
+	}
+}
+
+func TestNewBJSONFromReader(t *testing.T) {
+	type args struct {
+		r io.Reader
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success",
+			args:    args{r: strings.NewReader(`{"a":"str","b":[1]}`)},
+			want:    `{"a":"str","b":[1]}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - invalid json",
+			args:    args{r: strings.NewReader("[1,\n2,]")},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - read error",
+			args:    args{r: iotest.ErrReader(errors.New("boom"))},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBJSONFromReader(tt.args.r)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestNewBJSONWithOptions(t *testing.T) {
+	type args struct {
+		data interface{}
+		opts []Option
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - without options",
+			args:    args{data: `{"a":1}`, opts: nil},
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - keys within limit",
+			args:    args{data: []byte(`{"a":{"b":1,"c":[{"d":{}},{}]},"e":"{\"x\":1}"}`), opts: []Option{WithMaxKeys(5)}},
+			want:    `{"a":{"b":1,"c":[{"d":{}},{}]},"e":"{\"x\":1}"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - string values are not keys",
+			args:    args{data: `["a","b","c",{"k":"v"}]`, opts: []Option{WithMaxKeys(1)}},
+			want:    `["a","b","c",{"k":"v"}]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - unlimited",
+			args:    args{data: map[string]interface{}{"a": 1, "b": 2}, opts: []Option{WithMaxKeys(0)}},
+			want:    `{"a":1,"b":2}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - too many nested keys",
+			args:    args{data: `{"a":{"b":1,"c":[{"d":{}},{"e":1,"f":2}]}}`, opts: []Option{WithMaxKeys(5)}},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name: "fail - too many keys from bjson",
+			args: args{data: func() BJSON {
+				bj, err := NewBJSON(`{"a":1,"b":2}`)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return bj
+			}(), opts: []Option{WithMaxKeys(1)}},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "success - use number keeps 19 digit integer and long decimal",
+			args:    args{data: `{"id":9223372036854775807,"big":9007199254740993,"dec":3.14159265358979323846264}`, opts: []Option{WithUseNumber()}},
+			want:    `{"big":9007199254740993,"dec":3.14159265358979323846264,"id":9223372036854775807}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - use number with max keys",
+			args:    args{data: `[1.50,{"a":1e3}]`, opts: []Option{WithUseNumber(), WithMaxKeys(1)}},
+			want:    `[1.50,{"a":1e3}]`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - invalid json",
+			args:    args{data: `{"a":`, opts: []Option{WithMaxKeys(5)}},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - invalid json with use number",
+			args:    args{data: `{"a":1}}`, opts: []Option{WithUseNumber()}},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - empty input with use number",
+			args:    args{data: ``, opts: []Option{WithUseNumber()}},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBJSONWithOptions(tt.args.data, tt.args.opts...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestWithUseNumber_preservesPrecision(t *testing.T) {
+	bj, err := NewBJSONWithOptions(`{"id":1234567890123456789,"list":[0.12345678901234567890]}`, WithUseNumber())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := bj.GetElement("id")
+	assert.NoError(t, err)
+	assert.Equal(t, `1234567890123456789`, got.String())
+
+	id, err := bj.GetInt("id")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1234567890123456789), id)
+
+	assert.NoError(t, bj.AddElement(json.Number("9007199254740993"), "big"))
+	assert.NoError(t, bj.AddElement(1, "list"))
+
+	cp, err := bj.Copy()
+	assert.NoError(t, err)
+	assert.NoError(t, bj.Atomic(func(scratch BJSON) error {
+		return scratch.RenameKey("renamed", "big")
+	}))
+
+	want := `{"id":1234567890123456789,"list":[0.12345678901234567890,1],"renamed":9007199254740993}`
+	assert.Equal(t, want, bj.String())
+	assert.Equal(t, `{"big":9007199254740993,"id":1234567890123456789,"list":[0.12345678901234567890,1]}`, cp.String())
+}
+
+func TestWithUseNumber_keepsLargeIntegers(t *testing.T) {
+	type args struct {
+		fn func(bj BJSON) error
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "success - set int64 above 2^53",
+			args: args{fn: func(bj BJSON) error {
+				return bj.SetElement(int64(9007199254740993), "a")
+			}},
+			want:    `{"a":9007199254740993}`,
+			wantErr: false,
+		},
+		{
+			name: "success - add uint64 inside a json array",
+			args: args{fn: func(bj BJSON) error {
+				return bj.AddElement([]uint64{18446744073709551615}, "b")
+			}},
+			want:    `{"a":1,"b":[18446744073709551615]}`,
+			wantErr: false,
+		},
+		{
+			name: "success - merge",
+			args: args{fn: func(bj BJSON) error {
+				return bj.Merge(`{"c":12345678901234567891}`)
+			}},
+			want:    `{"a":1,"c":12345678901234567891}`,
+			wantErr: false,
+		},
+		{
+			name: "success - deep merge",
+			args: args{fn: func(bj BJSON) error {
+				return bj.DeepMerge([]byte(`{"c":12345678901234567891}`))
+			}},
+			want:    `{"a":1,"c":12345678901234567891}`,
+			wantErr: false,
+		},
+		{
+			name: "success - apply patch",
+			args: args{fn: func(bj BJSON) error {
+				return bj.ApplyPatch([]byte(`[{"op":"add","path":"/c","value":12345678901234567891}]`))
+			}},
+			want:    `{"a":1,"c":12345678901234567891}`,
+			wantErr: false,
+		},
+		{
+			name: "success - coerce by map",
+			args: args{fn: func(bj BJSON) error {
+				if err := bj.AddElement("12345678901234567891", "c"); err != nil {
+					return err
+				}
+
+				return bj.CoerceByMap(map[string]JSONType{"c": JSONTypeNumber})
+			}},
+			want:    `{"a":1,"c":12345678901234567891}`,
+			wantErr: false,
+		},
+		{
+			name: "success - unescape element",
+			args: args{fn: func(bj BJSON) error {
+				if err := bj.AddElement(`{"c":12345678901234567891}`, "b"); err != nil {
+					return err
+				}
+
+				return bj.UnescapeElement("b")
+			}},
+			want:    `{"a":1,"b":{"c":12345678901234567891}}`,
+			wantErr: false,
+		},
+		{
+			name: "success - canonicalize escaping",
+			args: args{fn: func(bj BJSON) error {
+				if err := bj.SetElement(`{"c":12345678901234567891}`); err != nil {
+					return err
+				}
+
+				return bj.CanonicalizeEscaping()
+			}},
+			want:    `{"c":12345678901234567891}`,
+			wantErr: false,
+		},
+		{
+			name: "success - set through a ForEach view",
+			args: args{fn: func(bj BJSON) error {
+				if err := bj.AddElement(map[string]interface{}{}, "o"); err != nil {
+					return err
+				}
+
+				return bj.ForEach(func(key string, value BJSON) error {
+					if key != "o" {
+						return nil
+					}
+
+					return value.AddElement(json.RawMessage("9007199254740993"), "b")
+				})
+			}},
+			want:    `{"a":1,"o":{"b":9007199254740993}}`,
+			wantErr: false,
+		},
+		{
+			name: "success - set through a Walk view",
+			args: args{fn: func(bj BJSON) error {
+				return bj.Walk(func(path []string, value BJSON) error {
+					if len(path) != 0 {
+						return nil
+					}
+
+					return value.AddElement(json.RawMessage("9007199254740993"), "b")
+				})
+			}},
+			want:    `{"a":1,"b":9007199254740993}`,
+			wantErr: false,
+		},
+		{
+			name: "fail - set invalid json number",
+			args: args{fn: func(bj BJSON) error {
+				return bj.SetElement(json.Number("abc"), "a")
+			}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name: "fail - merge with invalid patch",
+			args: args{fn: func(bj BJSON) error {
+				return bj.Merge(`{"c":`)
+			}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSONWithOptions(`{"a":1}`, WithUseNumber())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tt.args.fn(bj)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func TestWithUseNumber_comparesExactly(t *testing.T) {
+	newBJSON := func(data string) BJSON {
+		bj, err := NewBJSONWithOptions(data, WithUseNumber())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return bj
+	}
+
+	odd, even := newBJSON(`{"n":9007199254740993}`), newBJSON(`{"n":9007199254740992}`)
+	assert.False(t, odd.Equal(even))
+	assert.True(t, odd.Equal(newBJSON(`{"n":9007199254740993.0}`)))
+	assert.True(t, odd.RoundTripStable())
+
+	// against a float64 the json.Number is compared as a float64
+	parsed, err := NewBJSON(`{"n":9007199254740992}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, even.Equal(parsed))
+	assert.True(t, odd.Equal(parsed))
+
+	changes, err := odd.Diff(even)
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, []string{"n"}, changes[0].Path)
+		assert.Equal(t, ChangeModified, changes[0].Op)
+		assert.Equal(t, `9007199254740993`, changes[0].Old.String())
+		assert.Equal(t, `9007199254740992`, changes[0].New.String())
+	}
+
+	paths, err := odd.ChangedPaths(even)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"n"}}, paths)
+
+	patch, err := odd.GeneratePatch(even)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"op":"replace","path":"/n","value":9007199254740992}]`, string(patch))
+}
+
+func TestWithUseNumber_comparesWithPlainDocument(t *testing.T) {
+	withNumber, err := NewBJSONWithOptions(`{"a":0.1,"b":[1e3,{"c":2.50}]}`, WithUseNumber())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := NewBJSON(`{"a":0.1,"b":[1000,{"c":2.5}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, withNumber.Equal(plain))
+	assert.True(t, plain.Equal(withNumber))
+	assert.True(t, withNumber.IsSubsetOf(plain))
+	assert.Equal(t, [][]string{{"a"}}, withNumber.FindValue(0.1))
+
+	paths, err := withNumber.ChangedPaths(plain)
+	assert.NoError(t, err)
+	assert.Empty(t, paths)
+
+	changes, err := plain.Diff(withNumber)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	assert.NoError(t, withNumber.ApplyPatch([]byte(`[{"op":"test","path":"/a","value":0.1}]`)))
+	assert.NoError(t, plain.ApplyPatch([]byte(`[{"op":"test","path":"/b/0","value":1e3}]`)))
+
+	n, err := withNumber.GetInt("b", "0")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), n)
+
+	_, err = withNumber.GetInt("b", "1", "c")
+	assert.Error(t, err)
+}
+
 func TestNewJSONElementFromFile(t *testing.T) {
 	// add valid json
 	validPath := path.Join(os.TempDir(), "bjson_test_valid.json")
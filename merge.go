@@ -0,0 +1,264 @@
+package bjson
+
+import (
+	"fmt"
+)
+
+type mergeResolver func(path []string, a, b interface{}) (interface{}, error)
+
+// MergeWithResolver deep-merges other into the element at targets. JSON objects merge key by
+// key and keys only present in other are added. Wherever both sides hold different values
+// that are not both JSON objects, resolve picks the result from the current value a and the
+// incoming value b; path is relative to targets. Returning an error from resolve aborts the
+// merge and leaves the document unchanged.
+func (bj *bjson) MergeWithResolver(other BJSON, resolve func(path []string, a, b BJSON) (interface{}, error), targets ...string) error {
+	if resolve == nil {
+		return fmt.Errorf("resolver is nil")
+	}
+
+	otherVal, err := valueOf(other)
+	if err != nil {
+		return err
+	}
+
+	return bj.merge(otherVal, func(path []string, a, b interface{}) (interface{}, error) {
+		ret, err := resolve(path, &bjson{value: a, useNumber: bj.useNumber}, &bjson{value: b, useNumber: bj.useNumber})
+		if err != nil {
+			return nil, fmt.Errorf("fail to resolve conflict at path '%v'. %v", formatPath(path), err)
+		}
+
+		return deepCopy(ret, bj.useNumber)
+	}, targets)
+}
+
+func (bj *bjson) merge(other interface{}, resolve mergeResolver, targets []string) error {
+	otherCopy, err := deepCopy(other, bj.useNumber)
+	if err != nil {
+		return err
+	}
+
+	return bj.atomic(func(scratch *bjson) error {
+		sel, err := scratch.getElement(newTracer(targets))
+		if err != nil {
+			return err
+		}
+
+		nVal, err := mergeElement(nil, sel.value, otherCopy, resolve)
+		if err != nil {
+			return err
+		}
+
+		return scratch.SetElement(nVal, targets...)
+	})
+}
+
+func mergeElement(path []string, dst, src interface{}, resolve mergeResolver) (interface{}, error) {
+	dstObj, isDstObj := dst.(map[string]interface{})
+	srcObj, isSrcObj := src.(map[string]interface{})
+	if !isDstObj || !isSrcObj {
+		if valuesEqual(dst, src) {
+			return dst, nil
+		}
+
+		return resolve(path, dst, src)
+	}
+
+	for _, key := range sortedKeys(srcObj) {
+		child, isExist := dstObj[key]
+		if !isExist {
+			dstObj[key] = srcObj[key]
+			continue
+		}
+
+		merged, err := mergeElement(appendPath(path, key), child, srcObj[key], resolve)
+		if err != nil {
+			return nil, err
+		}
+
+		dstObj[key] = merged
+	}
+
+	return dstObj, nil
+}
+
+// Merge applies patch as an RFC 7386 JSON Merge Patch. A string or []byte patch is parsed as
+// JSON. Objects merge key by key, a null value removes the key, and any other patch value,
+// arrays included, replaces the target.
+func (bj *bjson) Merge(patch interface{}) error {
+	if str, ok := patch.(string); ok {
+		patch = []byte(str)
+	}
+
+	patchVal, err := deepCopy(patch, bj.useNumber)
+	if err != nil {
+		return fmt.Errorf("fail to parse merge patch. %v", err)
+	}
+
+	bj.value = mergePatch(bj.value, patchVal)
+	return nil
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
+
+// MergeOption configures DeepMerge.
+type MergeOption func(*mergeOptions)
+
+type mergeOptions struct {
+	replaceArrays bool
+}
+
+// WithArrayConcat makes DeepMerge append the elements of an incoming array to the current
+// array at the same path. This is the default.
+func WithArrayConcat() MergeOption {
+	return func(o *mergeOptions) {
+		o.replaceArrays = false
+	}
+}
+
+// WithArrayReplace makes DeepMerge replace the current array with the incoming one.
+func WithArrayReplace() MergeOption {
+	return func(o *mergeOptions) {
+		o.replaceArrays = true
+	}
+}
+
+// DeepMerge merges other into the document. A string or []byte other is parsed as JSON.
+// Precedence, from first to last rule applied: a JSON object on one side and a non-object on
+// the other fails the merge and leaves the document unchanged; two JSON objects merge key by
+// key; two JSON arrays are concatenated, or replaced with WithArrayReplace; any other value
+// from other overwrites the current one. When opts conflict, the last one wins.
+func (bj *bjson) DeepMerge(other interface{}, opts ...MergeOption) error {
+	o := &mergeOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	if str, ok := other.(string); ok {
+		other = []byte(str)
+	}
+
+	otherVal, err := deepCopy(other, bj.useNumber)
+	if err != nil {
+		return fmt.Errorf("fail to parse merge source. %v", err)
+	}
+
+	return bj.atomic(func(scratch *bjson) error {
+		nVal, err := deepMergeElement(nil, scratch.value, otherVal, o)
+		if err != nil {
+			return err
+		}
+
+		scratch.value = nVal
+		return nil
+	})
+}
+
+func deepMergeElement(path []string, dst, src interface{}, o *mergeOptions) (interface{}, error) {
+	dstObj, isDstObj := dst.(map[string]interface{})
+	srcObj, isSrcObj := src.(map[string]interface{})
+	if isDstObj != isSrcObj {
+		return nil, fmt.Errorf("cannot merge json %v into json %v at path '%v'", typeOf(src), typeOf(dst), formatPath(path))
+	}
+
+	if isDstObj {
+		for _, key := range sortedKeys(srcObj) {
+			child, isExist := dstObj[key]
+			if !isExist {
+				dstObj[key] = srcObj[key]
+				continue
+			}
+
+			merged, err := deepMergeElement(appendPath(path, key), child, srcObj[key], o)
+			if err != nil {
+				return nil, err
+			}
+
+			dstObj[key] = merged
+		}
+
+		return dstObj, nil
+	}
+
+	dstArr, isDstArr := dst.([]interface{})
+	srcArr, isSrcArr := src.([]interface{})
+	if isDstArr && isSrcArr && !o.replaceArrays {
+		return append(dstArr, srcArr...), nil
+	}
+
+	return src, nil
+}
+
+func (bj *bjson) MergeArrayBy(key string, other []interface{}, targets ...string) error {
+	arr, err := bj.getArrayElement(targets)
+	if err != nil {
+		return err
+	}
+
+	otherCopy, err := deepCopy(other, bj.useNumber)
+	if err != nil {
+		return err
+	}
+
+	otherArr, _ := otherCopy.([]interface{})
+	ret := make([]interface{}, len(arr), len(arr)+len(otherArr))
+	copy(ret, arr)
+	for _, elem := range otherArr {
+		idx := indexByKey(ret, key, elem)
+		if idx < 0 {
+			ret = append(ret, elem)
+			continue
+		}
+
+		ret[idx] = elem
+	}
+
+	return bj.SetElement(ret, targets...)
+}
+
+func indexByKey(arr []interface{}, key string, elem interface{}) int {
+	obj, ok := elem.(map[string]interface{})
+	if !ok {
+		return -1
+	}
+
+	want, ok := obj[key]
+	if !ok {
+		return -1
+	}
+
+	for idx, v := range arr {
+		vObj, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if got, ok := vObj[key]; ok && valuesEqual(got, want) {
+			return idx
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,451 @@
+package bjson
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_bjson_MergeArrayBy(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		key     string
+		other   []interface{}
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "success - replace matching and append new records",
+			fields: fields{value: `{"users":[{"id":1,"name":"a"},{"id":2,"name":"b"}]}`},
+			args: args{
+				key: "id",
+				other: []interface{}{
+					map[string]interface{}{"id": 2, "name": "B"},
+					map[string]interface{}{"id": 3, "name": "c"},
+				},
+				targets: []string{"users"},
+			},
+			want:    `{"users":[{"id":1,"name":"a"},{"id":2,"name":"B"},{"id":3,"name":"c"}]}`,
+			wantErr: false,
+		},
+		{
+			name:   "success - append records without key",
+			fields: fields{value: `[{"id":1}]`},
+			args: args{
+				key:     "id",
+				other:   []interface{}{map[string]interface{}{"name": "x"}, "str"},
+				targets: nil,
+			},
+			want:    `[{"id":1},{"name":"x"},"str"]`,
+			wantErr: false,
+		},
+		{
+			name:   "fail - target is not a json array",
+			fields: fields{value: `{"users":{}}`},
+			args: args{
+				key:     "id",
+				other:   []interface{}{map[string]interface{}{"id": 1}},
+				targets: []string{"users"},
+			},
+			want:    `{"users":{}}`,
+			wantErr: true,
+		},
+		{
+			name:   "fail - element is not found",
+			fields: fields{value: `{}`},
+			args: args{
+				key:     "id",
+				other:   nil,
+				targets: []string{"users"},
+			},
+			want:    `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.MergeArrayBy(tt.args.key, tt.args.other, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_MergeWithResolver(t *testing.T) {
+	preferIncoming := func(path []string, a, b BJSON) (interface{}, error) {
+		return b, nil
+	}
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		other   interface{}
+		resolve func(path []string, a, b BJSON) (interface{}, error)
+		targets []string
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		want      string
+		wantPaths []string
+		wantErr   bool
+	}{
+		{
+			name:   "success - merge objects and resolve leaf conflicts",
+			fields: fields{value: `{"a":1,"b":{"c":"x","d":[1]},"e":true}`},
+			args: args{
+				other:   `{"a":2,"b":{"c":"x","d":{"k":1},"f":null},"g":"new"}`,
+				resolve: preferIncoming,
+				targets: nil,
+			},
+			want:      `{"a":2,"b":{"c":"x","d":{"k":1},"f":null},"e":true,"g":"new"}`,
+			wantPaths: []string{"a", "b.d"},
+			wantErr:   false,
+		},
+		{
+			name:   "success - resolver keeps longer string",
+			fields: fields{value: `{"x":{"name":"long name"}}`},
+			args: args{
+				other: `{"name":"short"}`,
+				resolve: func(path []string, a, b BJSON) (interface{}, error) {
+					if len(a.String()) >= len(b.String()) {
+						return a, nil
+					}
+					return b, nil
+				},
+				targets: []string{"x"},
+			},
+			want:      `{"x":{"name":"long name"}}`,
+			wantPaths: []string{"name"},
+			wantErr:   false,
+		},
+		{
+			name:   "success - conflicting root scalars",
+			fields: fields{value: `1`},
+			args: args{
+				other: `2`,
+				resolve: func(path []string, a, b BJSON) (interface{}, error) {
+					return "resolved", nil
+				},
+				targets: nil,
+			},
+			want:      `"resolved"`,
+			wantPaths: []string{""},
+			wantErr:   false,
+		},
+		{
+			name:   "fail - resolver aborts",
+			fields: fields{value: `{"a":1,"b":1}`},
+			args: args{
+				other: `{"a":1,"b":2,"c":3}`,
+				resolve: func(path []string, a, b BJSON) (interface{}, error) {
+					return nil, errors.New("conflict")
+				},
+				targets: nil,
+			},
+			want:      `{"a":1,"b":1}`,
+			wantPaths: []string{"b"},
+			wantErr:   true,
+		},
+		{
+			name:      "fail - resolver is nil",
+			fields:    fields{value: `{"a":1}`},
+			args:      args{other: `{"a":2}`, resolve: nil, targets: nil},
+			want:      `{"a":1}`,
+			wantPaths: nil,
+			wantErr:   true,
+		},
+		{
+			name:      "fail - element is not found",
+			fields:    fields{value: `{"a":1}`},
+			args:      args{other: `{"a":2}`, resolve: preferIncoming, targets: []string{"x"}},
+			want:      `{"a":1}`,
+			wantPaths: nil,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			other, err := NewBJSON(tt.args.other)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotPaths []string
+			resolve := tt.args.resolve
+			if resolve != nil {
+				resolve = func(path []string, a, b BJSON) (interface{}, error) {
+					gotPaths = append(gotPaths, formatPath(path))
+					return tt.args.resolve(path, a, b)
+				}
+			}
+
+			err = bj.MergeWithResolver(other, resolve, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+			assert.Equal(t, tt.wantPaths, gotPaths)
+		})
+	}
+}
+
+func Test_bjson_Merge(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		patch interface{}
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - rfc example document",
+			fields:  fields{value: `{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"],"content":"This will be unchanged"}`},
+			args:    args{patch: `{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`},
+			want:    `{"author":{"givenName":"John"},"content":"This will be unchanged","phoneNumber":"+01-123-456-7890","tags":["example"],"title":"Hello!"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - replace value",
+			fields:  fields{value: `{"a":"b"}`},
+			args:    args{patch: `{"a":"c"}`},
+			want:    `{"a":"c"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - add key",
+			fields:  fields{value: `{"a":"b"}`},
+			args:    args{patch: `{"b":"c"}`},
+			want:    `{"a":"b","b":"c"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - null removes key",
+			fields:  fields{value: `{"a":"b","b":"c"}`},
+			args:    args{patch: `{"a":null}`},
+			want:    `{"b":"c"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - array replaces array",
+			fields:  fields{value: `{"a":["b"]}`},
+			args:    args{patch: `{"a":"c"}`},
+			want:    `{"a":"c"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - object replaces scalar",
+			fields:  fields{value: `{"a":"foo"}`},
+			args:    args{patch: `{"a":{"b":"c"}}`},
+			want:    `{"a":{"b":"c"}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - nested null is dropped from new object",
+			fields:  fields{value: `{"e":null}`},
+			args:    args{patch: `{"a":1,"b":{"c":null}}`},
+			want:    `{"a":1,"b":{},"e":null}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - current array is replaced by object",
+			fields:  fields{value: `[1,2]`},
+			args:    args{patch: `{"a":"b","c":null}`},
+			want:    `{"a":"b"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - scalar root",
+			fields:  fields{value: `"foo"`},
+			args:    args{patch: []byte(`{"a":1}`)},
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - non object patch replaces document",
+			fields:  fields{value: `{"a":"foo"}`},
+			args:    args{patch: []byte(`null`)},
+			want:    `null`,
+			wantErr: false,
+		},
+		{
+			name:    "success - native patch",
+			fields:  fields{value: `{"a":{"b":1}}`},
+			args:    args{patch: map[string]interface{}{"a": map[string]interface{}{"c": []int{1}}}},
+			want:    `{"a":{"b":1,"c":[1]}}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - invalid patch",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{patch: `{"a":`},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.Merge(tt.args.patch)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_DeepMerge(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		other interface{}
+		opts  []MergeOption
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "success - nested objects and arrays concatenate",
+			fields: fields{value: `{"a":{"tags":["x"],"n":1},"list":[{"id":1}]}`},
+			args: args{
+				other: `{"a":{"tags":["y","x"],"n":2,"m":true},"list":[{"id":2}]}`,
+				opts:  nil,
+			},
+			want:    `{"a":{"m":true,"n":2,"tags":["x","y","x"]},"list":[{"id":1},{"id":2}]}`,
+			wantErr: false,
+		},
+		{
+			name:   "success - arrays replace",
+			fields: fields{value: `{"a":{"tags":["x"]},"b":[1]}`},
+			args: args{
+				other: []byte(`{"a":{"tags":["y"]}}`),
+				opts:  []MergeOption{WithArrayReplace()},
+			},
+			want:    `{"a":{"tags":["y"]},"b":[1]}`,
+			wantErr: false,
+		},
+		{
+			name:   "success - last option wins",
+			fields: fields{value: `[1]`},
+			args: args{
+				other: `[2]`,
+				opts:  []MergeOption{WithArrayReplace(), WithArrayConcat()},
+			},
+			want:    `[1,2]`,
+			wantErr: false,
+		},
+		{
+			name:   "success - scalars and nulls overwrite",
+			fields: fields{value: `{"a":1,"b":"x","c":[1]}`},
+			args: args{
+				other: map[string]interface{}{"a": nil, "b": false, "c": "str"},
+				opts:  nil,
+			},
+			want:    `{"a":null,"b":false,"c":"str"}`,
+			wantErr: false,
+		},
+		{
+			name:   "fail - object and scalar conflict",
+			fields: fields{value: `{"a":{"b":{"c":1}},"x":[1]}`},
+			args: args{
+				other: `{"x":[2],"a":{"b":5}}`,
+				opts:  nil,
+			},
+			want:    `{"a":{"b":{"c":1}},"x":[1]}`,
+			wantErr: true,
+		},
+		{
+			name:   "fail - scalar and object conflict",
+			fields: fields{value: `{"a":"s"}`},
+			args: args{
+				other: `{"a":{"b":1}}`,
+				opts:  nil,
+			},
+			want:    `{"a":"s"}`,
+			wantErr: true,
+		},
+		{
+			name:   "fail - object and array conflict at root",
+			fields: fields{value: `{}`},
+			args: args{
+				other: `[]`,
+				opts:  nil,
+			},
+			want:    `{}`,
+			wantErr: true,
+		},
+		{
+			name:   "fail - invalid source",
+			fields: fields{value: `{}`},
+			args: args{
+				other: `{`,
+				opts:  nil,
+			},
+			want:    `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.DeepMerge(tt.args.other, tt.args.opts...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
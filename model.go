@@ -1,9 +1,102 @@
 package bjson
 
+import (
+	"fmt"
+)
+
 type updateOption string
 
 const (
-	uoAdd    updateOption = "add"
-	uoSet    updateOption = "set"
-	uoRemove updateOption = "remove"
+	uoAdd      updateOption = "add"
+	uoSet      updateOption = "set"
+	uoSetForce updateOption = "force set"
+	uoRemove   updateOption = "remove"
+)
+
+type JSONType string
+
+const (
+	JSONTypeObject JSONType = "object"
+	JSONTypeArray  JSONType = "array"
+	JSONTypeString JSONType = "string"
+	JSONTypeNumber JSONType = "number"
+	JSONTypeBool   JSONType = "boolean"
+	JSONTypeNull   JSONType = "null"
+
+	// JSONTypeDate is a string holding an RFC 3339 timestamp. It is only reported by type inference.
+	JSONTypeDate JSONType = "date"
 )
+
+func (t JSONType) String() string {
+	return string(t)
+}
+
+// TypeError reports an element that exists but holds another JSON type than requested.
+type TypeError struct {
+	Path string
+	Want JSONType
+	Got  JSONType
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("element %v is not a json %v. got: %v", e.Path, e.Want, e.Got)
+}
+
+type KeyCase string
+
+const (
+	KeyCaseNone  KeyCase = ""
+	KeyCaseLower KeyCase = "lower"
+	KeyCaseUpper KeyCase = "upper"
+	KeyCaseSnake KeyCase = "snake"
+	KeyCaseCamel KeyCase = "camel"
+)
+
+// Match is an element found by Where together with its path.
+type Match struct {
+	Path  []string
+	Value BJSON
+}
+
+// ControlCharPolicy selects how SanitizeControlChars rewrites control characters.
+type ControlCharPolicy string
+
+const (
+	// ControlCharStrip removes control characters.
+	ControlCharStrip ControlCharPolicy = "strip"
+	// ControlCharSpace replaces every control character with a single space.
+	ControlCharSpace ControlCharPolicy = "space"
+)
+
+// NormalizeRules selects the steps applied by Normalize. Steps run in field order.
+type NormalizeRules struct {
+	// TrimStrings removes leading and trailing white space from string values.
+	TrimStrings bool
+	// CoerceNumbers converts string values holding a JSON number literal into numbers like
+	// CoerceByMap. Literals a float64 cannot hold are left as strings unless the document
+	// was built WithUseNumber.
+	CoerceNumbers bool
+	// CoerceBooleans converts string values accepted by CoerceBooleans into booleans.
+	CoerceBooleans bool
+	// PruneNulls removes null JSON object members and null JSON array elements.
+	PruneNulls bool
+	// KeyCase renames JSON object keys into the selected case.
+	KeyCase KeyCase
+}
+
+type ChangeOp string
+
+const (
+	ChangeAdded    ChangeOp = "added"
+	ChangeRemoved  ChangeOp = "removed"
+	ChangeModified ChangeOp = "modified"
+)
+
+// Change is a difference found by Diff. Old is nil for added elements and New is nil for
+// removed elements.
+type Change struct {
+	Path []string
+	Op   ChangeOp
+	Old  BJSON
+	New  BJSON
+}
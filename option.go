@@ -0,0 +1,113 @@
+package bjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Option configures how NewBJSONWithOptions builds a document.
+type Option func(*options)
+
+type options struct {
+	maxKeys   int
+	useNumber bool
+}
+
+// WithMaxKeys makes parsing fail as soon as the document holds more than n JSON object
+// members in total, counting the members of nested objects. n <= 0 means unlimited, which
+// is the default.
+func WithMaxKeys(n int) Option {
+	return func(o *options) {
+		o.maxKeys = n
+	}
+}
+
+// WithUseNumber decodes numbers as json.Number instead of float64, so large integers and long
+// decimals keep their exact literal through Get, Set, Copy and Marshal. Values later set or
+// merged into the document are decoded the same way.
+func WithUseNumber() Option {
+	return func(o *options) {
+		o.useNumber = true
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	return o
+}
+
+type keyCounterFrame struct {
+	isObject  bool
+	expectKey bool
+}
+
+// checkMaxKeys streams the tokens of data and fails once more than maxKeys object members are
+// read, before the document is built. Malformed input is left to the regular decoding.
+func checkMaxKeys(data []byte, maxKeys int) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var (
+		stack []keyCounterFrame
+		count int
+	)
+
+	endValue := func() {
+		if len(stack) > 0 && stack[len(stack)-1].isObject {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			// io.EOF ends the document, syntax errors are reported by the regular decoding
+			return nil
+		}
+
+		if _, isKey := tok.(string); isKey && len(stack) > 0 && stack[len(stack)-1].expectKey {
+			stack[len(stack)-1].expectKey = false
+			if count++; count > maxKeys {
+				return fmt.Errorf("document exceeds the maximum of %v keys", maxKeys)
+			}
+
+			continue
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, keyCounterFrame{isObject: true, expectKey: true})
+		case json.Delim('['):
+			stack = append(stack, keyCounterFrame{isObject: false})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			endValue()
+		default:
+			endValue()
+		}
+	}
+}
+
+// unmarshalUseNumber parses data like json.Unmarshal, but decodes numbers as json.Number.
+func unmarshalUseNumber(data []byte) (interface{}, error) {
+	var ret interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&ret); err == nil && len(bytes.TrimSpace(data[dec.InputOffset():])) == 0 {
+		return ret, nil
+	}
+
+	// report the same error json.Unmarshal would
+	err := json.Unmarshal(data, &ret)
+	if err == nil {
+		err = errors.New("invalid JSON")
+	}
+
+	return nil, decodeError(data, err)
+}
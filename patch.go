@@ -0,0 +1,122 @@
+package bjson
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// GeneratePatch returns an RFC 6902 JSON Patch turning the document into target. It only uses
+// add, remove and replace operations, one per change reported by Diff.
+func (bj *bjson) GeneratePatch(target BJSON) ([]byte, error) {
+	targetVal, err := valueOf(target)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make([]map[string]interface{}, 0)
+	diffElement(nil, bj.value, targetVal, true, func(path []string, op ChangeOp, oldVal, newVal interface{}) {
+		switch op {
+		case ChangeAdded:
+			ops = append(ops, map[string]interface{}{"op": "add", "path": formatPointer(path), "value": newVal})
+		case ChangeRemoved:
+			ops = append(ops, map[string]interface{}{"op": "remove", "path": formatPointer(path)})
+		case ChangeModified:
+			ops = append(ops, map[string]interface{}{"op": "replace", "path": formatPointer(path), "value": newVal})
+		}
+	})
+
+	return json.Marshal(ops)
+}
+
+// ApplyPatch applies an RFC 6902 JSON Patch supporting the add, remove, replace, move, copy and
+// test operations. Either every operation is applied or, when any of them fails, none is.
+func (bj *bjson) ApplyPatch(patch []byte) error {
+	var ops []patchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return fmt.Errorf("fail to parse json patch. %v", decodeError(patch, err))
+	}
+
+	return bj.atomic(func(scratch *bjson) error {
+		for idx, op := range ops {
+			if err := scratch.applyPatchOperation(op); err != nil {
+				return fmt.Errorf("fail to apply json patch operation %v '%v'. %v", idx, op.Op, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (bj *bjson) applyPatchOperation(op patchOperation) error {
+	targets, err := parsePointer(op.Path)
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return fmt.Errorf("value is missing")
+		}
+
+		if value, err = deepCopy([]byte(op.Value), bj.useNumber); err != nil {
+			return err
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		_, err = bj.placeElement(value, targets)
+		return err
+
+	case "remove":
+		return bj.RemoveElement(targets...)
+
+	case "replace":
+		if _, err = bj.getElement(newTracer(targets)); err != nil {
+			return err
+		}
+
+		return bj.SetElement(value, targets...)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return err
+		}
+
+		if op.Op == "move" {
+			return bj.MoveElement(from, targets)
+		}
+
+		sel, err := bj.getElement(newTracer(from))
+		if err != nil {
+			return err
+		}
+
+		_, err = bj.placeElement(sel.value, targets)
+		return err
+
+	case "test":
+		sel, err := bj.getElement(newTracer(targets))
+		if err != nil {
+			return err
+		}
+
+		if !valuesEqual(sel.value, value) {
+			return fmt.Errorf("element %v is not equal to %v", parseTracerPath(targets), string(op.Value))
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown operation")
+}
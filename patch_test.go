@@ -0,0 +1,254 @@
+package bjson
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_bjson_GeneratePatch(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		target interface{}
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - replace single scalar",
+			fields:  fields{value: `{"a":{"b":1},"c":"x"}`},
+			args:    args{target: `{"a":{"b":2},"c":"x"}`},
+			want:    `[{"op":"replace","path":"/a/b","value":2}]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - add and remove keys with escaped pointers",
+			fields:  fields{value: `{"a/b":1,"k":null}`},
+			args:    args{target: `{"k":null,"m~n":null}`},
+			want:    `[{"op":"remove","path":"/a~1b"},{"op":"add","path":"/m~0n","value":null}]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - remove trailing array elements from the last",
+			fields:  fields{value: `[1,2,3,4]`},
+			args:    args{target: `[1,5]`},
+			want:    `[{"op":"replace","path":"/1","value":5},{"op":"remove","path":"/3"},{"op":"remove","path":"/2"}]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - equal documents",
+			fields:  fields{value: `{"a":[1]}`},
+			args:    args{target: `{"a":[1.0]}`},
+			want:    `[]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - replace root",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{target: `[1]`},
+			want:    `[{"op":"replace","path":"","value":[1]}]`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - nil target",
+			fields:  fields{value: `{}`},
+			args:    args{target: nil},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var target BJSON
+			if tt.args.target != nil {
+				target, err = NewBJSON(tt.args.target)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := bj.GeneratePatch(target)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func Test_bjson_GeneratePatch_roundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		target string
+	}{
+		{
+			name:   "success - nested objects and arrays",
+			source: `{"users":[{"id":1,"tags":["a","b","c"]},{"id":2}],"meta":{"v":1,"old":true}}`,
+			target: `{"users":[{"id":1,"tags":["a"],"name":"x"},{"id":2},{"id":3}],"meta":{"v":2,"new":[null]}}`,
+		},
+		{
+			name:   "success - type changes",
+			source: `{"a":{"b":1},"c":[1],"d":"s"}`,
+			target: `{"a":[1],"c":{"x":1},"d":null}`,
+		},
+		{
+			name:   "success - shrink nested arrays",
+			source: `[[1,2,3],[4,5],6]`,
+			target: `[[1],[]]`,
+		},
+		{
+			name:   "success - special keys",
+			source: `{"a/b":{"~":1},"":2}`,
+			target: `{"a/b":{"~":3,"/":4}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewBJSON(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			target, err := NewBJSON(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patch, err := source.GeneratePatch(target)
+			assert.NoError(t, err)
+
+			cp, err := source.Copy()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.NoError(t, cp.ApplyPatch(patch))
+			assert.True(t, cp.Equal(target), "got %v", cp.String())
+		})
+	}
+}
+
+func Test_bjson_ApplyPatch(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		patch string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - add object member and insert array element",
+			fields:  fields{value: `{"foo":["bar","baz"]}`},
+			args:    args{patch: `[{"op":"add","path":"/baz","value":"qux"},{"op":"add","path":"/foo/1","value":"x"}]`},
+			want:    `{"baz":"qux","foo":["bar","x","baz"]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - add to end of array and null value",
+			fields:  fields{value: `{"foo":[1]}`},
+			args:    args{patch: `[{"op":"add","path":"/foo/-","value":[2]},{"op":"add","path":"/n","value":null}]`},
+			want:    `{"foo":[1,[2]],"n":null}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - add at negative array index",
+			fields:  fields{value: `{"foo":["a","b"]}`},
+			args:    args{patch: `[{"op":"add","path":"/foo/-1","value":"x"},{"op":"add","path":"/foo/-3","value":"y"}]`},
+			want:    `{"foo":["y","a","x","b"]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - remove and replace",
+			fields:  fields{value: `{"baz":"qux","foo":"bar","arr":[1,2]}`},
+			args:    args{patch: `[{"op":"remove","path":"/baz"},{"op":"replace","path":"/foo","value":"boo"},{"op":"remove","path":"/arr/0"}]`},
+			want:    `{"arr":[2],"foo":"boo"}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - move and copy",
+			fields:  fields{value: `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`},
+			args:    args{patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"},{"op":"copy","from":"/qux","path":"/foo/q"}]`},
+			want:    `{"foo":{"bar":"baz","q":{"corge":"grault","thud":"fred"}},"qux":{"corge":"grault","thud":"fred"}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - test passes",
+			fields:  fields{value: `{"a":[1,{"b":"c"}]}`},
+			args:    args{patch: `[{"op":"test","path":"/a","value":[1.0,{"b":"c"}]}]`},
+			want:    `{"a":[1,{"b":"c"}]}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - test fails and rolls back",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{patch: `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":3}]`},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - replace missing element",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{patch: `[{"op":"replace","path":"/b","value":2}]`},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - missing value",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{patch: `[{"op":"add","path":"/b"}]`},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - unknown operation",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{patch: `[{"op":"increment","path":"/a"}]`},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - invalid patch document",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{patch: `{"op":"remove","path":"/a"}`},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.ApplyPatch([]byte(tt.args.patch))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
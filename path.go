@@ -0,0 +1,585 @@
+package bjson
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	pathSeparator = '.'
+	pathEscape    = '\\'
+	pathWildcard  = "*"
+	pathQuote     = '`'
+	quoteEscaped  = "``"
+)
+
+type pathValue struct {
+	path  []string
+	value interface{}
+}
+
+type pathTrie struct {
+	isEnd    bool
+	children map[string]*pathTrie
+}
+
+func newPathTrie(paths [][]string) *pathTrie {
+	root := &pathTrie{}
+	for _, path := range paths {
+		node := root
+		for _, target := range path {
+			if node.children == nil {
+				node.children = make(map[string]*pathTrie)
+			}
+
+			child, ok := node.children[target]
+			if !ok {
+				child = &pathTrie{}
+				node.children[target] = child
+			}
+			node = child
+		}
+		node.isEnd = true
+	}
+
+	return root
+}
+
+// formatPath renders targets as a canonical dot-path such as "data.phone.0".
+// Separators, brackets and backslashes inside a target are escaped with a backslash, as is a
+// backtick starting a target so it is not read as a quoted segment (see QuoteSegment), and an
+// empty target is quoted. The root element is rendered as an empty string.
+func formatPath(targets []string) string {
+	var sb strings.Builder
+	for i, target := range targets {
+		if i > 0 {
+			sb.WriteByte(pathSeparator)
+		}
+
+		if target == "" {
+			sb.WriteString(QuoteSegment(target))
+			continue
+		}
+
+		for j, r := range target {
+			switch {
+			case r == pathSeparator, r == pathEscape, r == '[', r == ']':
+				sb.WriteByte(pathEscape)
+			case r == pathQuote && j == 0:
+				sb.WriteByte(pathEscape)
+			}
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
+
+// Detach returns the canonical dot-path of targets (see formatPath) together with an
+// independent deep copy of the element at targets, ready to be used as a cache entry.
+func (bj *bjson) Detach(targets ...string) (string, BJSON, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return "", nil, err
+	}
+
+	nVal, err := deepCopy(sel.value, bj.useNumber)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return formatPath(targets), &bjson{value: nVal, useNumber: bj.useNumber}, nil
+}
+
+// Extract returns a new document holding only the elements at paths together with their
+// ancestors, so ["a","b","c"] yields {"a":{"b":{"c":...}}}. Overlapping paths are merged and
+// paths that do not resolve are skipped. Ancestor JSON arrays keep only the extracted
+// elements, in their original order.
+func (bj *bjson) Extract(paths [][]string) (BJSON, error) {
+	nVal, ok := projectElement(bj.value, newPathTrie(absolutePaths(bj.value, paths)))
+	if !ok {
+		nVal = emptyLike(bj.value)
+	}
+
+	nVal, err := deepCopy(nVal, bj.useNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bjson{value: nVal, useNumber: bj.useNumber}, nil
+}
+
+// KeepOnly rewrites the document to hold only the elements at paths together with their
+// ancestors, following the rules of Extract.
+func (bj *bjson) KeepOnly(paths [][]string) error {
+	nVal, ok := projectElement(bj.value, newPathTrie(absolutePaths(bj.value, paths)))
+	if !ok {
+		nVal = emptyLike(bj.value)
+	}
+
+	bj.value = nVal
+	return nil
+}
+
+// projectElement returns value reduced to the paths of trie, sharing memory with value.
+// It reports false when none of the paths resolve.
+func projectElement(value interface{}, trie *pathTrie) (interface{}, bool) {
+	if trie.isEnd {
+		return value, true
+	}
+
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{})
+		for key, sub := range trie.children {
+			child, ok := obj[key]
+			if !ok {
+				continue
+			}
+
+			if projected, ok := projectElement(child, sub); ok {
+				ret[key] = projected
+			}
+		}
+
+		return ret, len(ret) > 0
+
+	case []interface{}:
+		var indices []int
+		for target := range trie.children {
+			if idx, err := strconv.Atoi(target); err == nil && idx >= 0 && idx < len(obj) {
+				indices = append(indices, idx)
+			}
+		}
+		sort.Ints(indices)
+
+		var ret []interface{}
+		for _, idx := range indices {
+			if projected, ok := projectElement(obj[idx], trie.children[strconv.Itoa(idx)]); ok {
+				ret = append(ret, projected)
+			}
+		}
+
+		return ret, len(ret) > 0
+	}
+
+	return nil, false
+}
+
+func emptyLike(value interface{}) interface{} {
+	switch value.(type) {
+	case map[string]interface{}:
+		return map[string]interface{}{}
+	case []interface{}:
+		return []interface{}{}
+	}
+
+	return nil
+}
+
+// ParsePath converts a dot-path such as "data.phone[0]" into targets. Segments are separated
+// by dots, bracketed segments such as "[0]" may follow a segment or start the path, and a
+// backslash escapes the following character so keys may contain dots or brackets. A segment
+// starting with a backtick is quoted (see QuoteSegment) and taken literally up to the closing
+// backtick. An empty path addresses the root element.
+func ParsePath(path string) ([]string, error) {
+	var (
+		ret         []string
+		seg         strings.Builder
+		runes       = []rune(path)
+		hasSeg      = false
+		expectSeg   = false
+		afterClosed = false
+	)
+
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case pathEscape:
+			if afterClosed {
+				return nil, fmt.Errorf("invalid path '%v': unexpected character at position %v", path, i)
+			}
+
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("invalid path '%v': trailing escape character", path)
+			}
+
+			i++
+			seg.WriteRune(runes[i])
+			hasSeg, expectSeg = true, false
+
+		case pathSeparator:
+			if !hasSeg && !afterClosed {
+				return nil, fmt.Errorf("invalid path '%v': empty segment at position %v", path, i)
+			}
+
+			if hasSeg {
+				ret = append(ret, seg.String())
+				seg.Reset()
+			}
+			hasSeg, expectSeg, afterClosed = false, true, false
+
+		case '[':
+			if expectSeg {
+				return nil, fmt.Errorf("invalid path '%v': empty segment at position %v", path, i)
+			}
+
+			if hasSeg {
+				ret = append(ret, seg.String())
+				seg.Reset()
+			}
+
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' && runes[end] != '[' {
+				end++
+			}
+
+			if end >= len(runes) || runes[end] != ']' {
+				return nil, fmt.Errorf("invalid path '%v': unbalanced bracket at position %v", path, i)
+			}
+
+			if end == i+1 {
+				return nil, fmt.Errorf("invalid path '%v': empty index at position %v", path, i)
+			}
+
+			ret = append(ret, string(runes[i+1:end]))
+			i = end
+			hasSeg, expectSeg, afterClosed = false, false, true
+
+		case ']':
+			return nil, fmt.Errorf("invalid path '%v': unbalanced bracket at position %v", path, i)
+
+		case pathQuote:
+			if hasSeg {
+				seg.WriteRune(runes[i])
+				continue
+			}
+
+			if afterClosed {
+				return nil, fmt.Errorf("invalid path '%v': unexpected character at position %v", path, i)
+			}
+
+			end := closingQuote(runes, i)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path '%v': unterminated quote at position %v", path, i)
+			}
+
+			ret = append(ret, strings.ReplaceAll(string(runes[i+1:end]), quoteEscaped, string(pathQuote)))
+			i = end
+			hasSeg, expectSeg, afterClosed = false, false, true
+
+		default:
+			if afterClosed {
+				return nil, fmt.Errorf("invalid path '%v': unexpected character at position %v", path, i)
+			}
+
+			seg.WriteRune(runes[i])
+			hasSeg, expectSeg = true, false
+		}
+	}
+
+	if expectSeg {
+		return nil, fmt.Errorf("invalid path '%v': trailing separator", path)
+	}
+
+	if hasSeg {
+		ret = append(ret, seg.String())
+	}
+
+	return ret, nil
+}
+
+// closingQuote returns the index of the backtick closing the quoted segment opened at start,
+// skipping doubled backticks, or -1 when the quote is not closed.
+func closingQuote(runes []rune, start int) int {
+	for i := start + 1; i < len(runes); i++ {
+		if runes[i] != pathQuote {
+			continue
+		}
+
+		if i+1 < len(runes) && runes[i+1] == pathQuote {
+			i++
+			continue
+		}
+
+		return i
+	}
+
+	return -1
+}
+
+// QuoteSegment quotes s as a single dot-path segment, so any key can be used in a string path
+// regardless of the dots, brackets, slashes or backslashes it contains. Backticks within s are
+// doubled, e.g. "a.b" becomes "`a.b`".
+func QuoteSegment(s string) string {
+	return string(pathQuote) + strings.ReplaceAll(s, string(pathQuote), quoteEscaped) + string(pathQuote)
+}
+
+// UnquoteSegment reverses QuoteSegment. It fails when s is not a single quoted segment.
+func UnquoteSegment(s string) (string, error) {
+	runes := []rune(s)
+	if len(runes) < 2 || runes[0] != pathQuote || closingQuote(runes, 0) != len(runes)-1 {
+		return "", fmt.Errorf("invalid quoted segment '%v'", s)
+	}
+
+	return strings.ReplaceAll(string(runes[1:len(runes)-1]), quoteEscaped, string(pathQuote)), nil
+}
+
+// GetElementPath is GetElement with targets given as a dot-path (see ParsePath).
+func (bj *bjson) GetElementPath(path string) (BJSON, error) {
+	targets, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return bj.GetElement(targets...)
+}
+
+// SetElementPath is SetElement with targets given as a dot-path (see ParsePath).
+func (bj *bjson) SetElementPath(value interface{}, path string) error {
+	targets, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+
+	return bj.SetElement(value, targets...)
+}
+
+// AddElementPath is AddElement with targets given as a dot-path (see ParsePath).
+func (bj *bjson) AddElementPath(value interface{}, path string) error {
+	targets, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+
+	return bj.AddElement(value, targets...)
+}
+
+// RemoveElementPath is RemoveElement with targets given as a dot-path (see ParsePath).
+func (bj *bjson) RemoveElementPath(path string) error {
+	targets, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+
+	return bj.RemoveElement(targets...)
+}
+
+// UpdateAll replaces every element matched by targets, which may contain "*" wildcards
+// (see resolveElements), with the result of fn called on a view of that element. The updates
+// are applied to a copy of the document and committed only when fn succeeds for every match.
+func (bj *bjson) UpdateAll(targets []string, fn func(current BJSON) (interface{}, error)) error {
+	return bj.atomic(func(scratch *bjson) error {
+		nodes, err := scratch.resolveElements(targets)
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			ret, err := fn(&bjson{value: node.value, useNumber: scratch.useNumber})
+			if err != nil {
+				return fmt.Errorf("fail to update element at path '%v'. %v", formatPath(node.path), err)
+			}
+
+			nVal, err := deepCopy(ret, scratch.useNumber)
+			if err != nil {
+				return err
+			}
+
+			if err = scratch.SetElement(nVal, node.path...); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ConflictingPaths groups the paths that affect each other: paths resolving to the same
+// element or where one resolves to an ancestor of another, so editing one changes the other.
+// Paths may contain "*" wildcards (see resolveElements), in which case every match is taken
+// into account. A path without wildcards that does not resolve, such as the target of a future
+// addition, is compared as written. Overlaps are transitive: when a contains both b and c, all
+// three form one group even if b and c are disjoint. Every group holds at least two paths,
+// rendered with formatPath in input order, and groups are ordered by their first path.
+func (bj *bjson) ConflictingPaths(paths [][]string) ([][]string, error) {
+	resolved := make([][][]string, len(paths))
+	for i, path := range paths {
+		nodes, err := bj.resolveElements(path)
+		if err != nil {
+			if containsString(path, pathWildcard) {
+				return nil, err
+			}
+
+			resolved[i] = absolutePaths(bj.value, [][]string{path})
+			continue
+		}
+
+		for _, node := range nodes {
+			resolved[i] = append(resolved[i], node.path)
+		}
+	}
+
+	group := make([]int, len(paths))
+	for i := range group {
+		group[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if group[i] != i {
+			group[i] = find(group[i])
+		}
+
+		return group[i]
+	}
+
+	for i := range paths {
+		for j := i + 1; j < len(paths); j++ {
+			if pathsOverlap(resolved[i], resolved[j]) {
+				group[find(j)] = find(i)
+			}
+		}
+	}
+
+	var (
+		groups  [][]string
+		indices = make(map[int]int)
+	)
+	for i, path := range paths {
+		root := find(i)
+		idx, isExist := indices[root]
+		if !isExist {
+			idx = len(groups)
+			indices[root] = idx
+			groups = append(groups, nil)
+		}
+
+		groups[idx] = append(groups[idx], formatPath(path))
+	}
+
+	var ret [][]string
+	for _, members := range groups {
+		if len(members) > 1 {
+			ret = append(ret, members)
+		}
+	}
+
+	return ret, nil
+}
+
+func pathsOverlap(a, b [][]string) bool {
+	for _, pathA := range a {
+		for _, pathB := range b {
+			if isPathPrefix(pathA, pathB) || isPathPrefix(pathB, pathA) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isPathPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolveElements resolves targets where a "*" target matches every key of a JSON object,
+// in sorted order, and every index of a JSON array. Targets before the first wildcard must
+// resolve like GetElement; after it, branches that do not resolve are skipped.
+func (bj *bjson) resolveElements(targets []string) ([]pathValue, error) {
+	tc := newTracer(targets)
+	nodes := []pathValue{{path: nil, value: bj.value}}
+	isExpanded := false
+	for tc.next() {
+		target := tc.currTarget()
+		var next []pathValue
+		for _, node := range nodes {
+			if target == pathWildcard {
+				switch obj := node.value.(type) {
+				case map[string]interface{}:
+					for _, key := range sortedKeys(obj) {
+						next = append(next, pathValue{path: appendPath(node.path, key), value: obj[key]})
+					}
+
+				case []interface{}:
+					for idx, child := range obj {
+						next = append(next, pathValue{path: appendPath(node.path, strconv.Itoa(idx)), value: child})
+					}
+				}
+
+				continue
+			}
+
+			child, ok := directChild(node.value, target)
+			if !ok {
+				if !isExpanded {
+					return nil, fmt.Errorf("element %v is not found. target: %v", tc.passedPath(), tc.originPath())
+				}
+
+				continue
+			}
+
+			next = append(next, pathValue{path: appendPath(node.path, absoluteTarget(node.value, target)), value: child})
+		}
+
+		if target == pathWildcard {
+			isExpanded = true
+		}
+		nodes = next
+	}
+
+	return nodes, nil
+}
+
+// absoluteTarget returns target as an index counted from the start when parent is a JSON
+// array, so a negative index names the same element as its absolute form. target must
+// resolve in parent.
+func absoluteTarget(parent interface{}, target string) string {
+	arr, ok := parent.([]interface{})
+	if !ok {
+		return target
+	}
+
+	idx, _ := strconv.Atoi(target)
+	if idx < 0 {
+		idx += len(arr)
+	}
+
+	return strconv.Itoa(idx)
+}
+
+// absolutePaths returns paths with every JSON array index that resolves in value counted
+// from the start. See absoluteTarget.
+func absolutePaths(value interface{}, paths [][]string) [][]string {
+	ret := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		nPath := make([]string, len(path))
+		copy(nPath, path)
+
+		curr := value
+		for i, target := range path {
+			child, ok := directChild(curr, target)
+			if !ok {
+				break
+			}
+
+			nPath[i] = absoluteTarget(curr, target)
+			curr = child
+		}
+		ret = append(ret, nPath)
+	}
+
+	return ret
+}
@@ -0,0 +1,737 @@
+package bjson
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func Test_bjson_Extract(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		paths [][]string
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   string
+	}{
+		{
+			name:   "success - extract nested paths",
+			fields: fields{value: `{"a":{"b":{"c":1,"d":2},"e":3},"f":4}`},
+			args:   args{paths: [][]string{{"a", "b", "c"}, {"f"}}},
+			want:   `{"a":{"b":{"c":1}},"f":4}`,
+		},
+		{
+			name:   "success - overlapping paths merge",
+			fields: fields{value: `{"a":{"b":{"c":1,"d":2},"e":3}}`},
+			args:   args{paths: [][]string{{"a", "b"}, {"a", "b", "c"}, {"a", "e"}}},
+			want:   `{"a":{"b":{"c":1,"d":2},"e":3}}`,
+		},
+		{
+			name:   "success - json array ancestors keep extracted elements in order",
+			fields: fields{value: `{"arr":[{"id":1,"x":1},{"id":2,"x":2},{"id":3,"x":3}]}`},
+			args:   args{paths: [][]string{{"arr", "2", "id"}, {"arr", "0", "id"}, {"arr", "9", "id"}}},
+			want:   `{"arr":[{"id":1},{"id":3}]}`,
+		},
+		{
+			name:   "success - negative indices merge with absolute indices",
+			fields: fields{value: `{"a":[{"x":1,"y":2},{"x":3,"y":4},{"x":5,"y":6}]}`},
+			args:   args{paths: [][]string{{"a", "-1", "x"}, {"a", "2", "y"}, {"a", "-3"}}},
+			want:   `{"a":[{"x":1,"y":2},{"x":5,"y":6}]}`,
+		},
+		{
+			name:   "success - missing paths are skipped",
+			fields: fields{value: `{"a":1}`},
+			args:   args{paths: [][]string{{"b", "c"}}},
+			want:   `{}`,
+		},
+		{
+			name:   "success - extract root",
+			fields: fields{value: `[1,2]`},
+			args:   args{paths: [][]string{{}}},
+			want:   `[1,2]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			got, err := bj.Extract(tt.args.paths)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+
+			// the extracted document must be independent of the original
+			assert.NoError(t, got.SetElement("changed"))
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func Test_bjson_KeepOnly(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		paths [][]string
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   string
+	}{
+		{
+			name:   "success - keep nested paths",
+			fields: fields{value: `{"user":{"id":1,"email":"a@b.c","profile":{"age":3,"ssn":"x"}},"token":"t"}`},
+			args:   args{paths: [][]string{{"user", "id"}, {"user", "profile", "age"}}},
+			want:   `{"user":{"id":1,"profile":{"age":3}}}`,
+		},
+		{
+			name:   "success - prefix path keeps full subtree",
+			fields: fields{value: `{"a":{"b":1,"c":2},"d":3}`},
+			args:   args{paths: [][]string{{"a"}, {"a", "b"}}},
+			want:   `{"a":{"b":1,"c":2}}`,
+		},
+		{
+			name:   "success - keep json array elements",
+			fields: fields{value: `[{"a":1,"b":2},{"a":3,"b":4}]`},
+			args:   args{paths: [][]string{{"1", "a"}}},
+			want:   `[{"a":3}]`,
+		},
+		{
+			name:   "success - nothing kept",
+			fields: fields{value: `{"a":1}`},
+			args:   args{paths: nil},
+			want:   `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.NoError(t, bj.KeepOnly(tt.args.paths))
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_UpdateAll(t *testing.T) {
+	round := func(current BJSON) (interface{}, error) {
+		v, err := current.ValueRef()
+		if err != nil {
+			return nil, err
+		}
+
+		f, ok := v.(float64)
+		if !ok {
+			return nil, errors.New("not a number")
+		}
+
+		return math.Round(f), nil
+	}
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+		fn      func(current BJSON) (interface{}, error)
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - update every match of a wildcard",
+			fields:  fields{value: `{"items":[{"price":1.4},{"price":2.6},{"name":"x"}]}`},
+			args:    args{targets: []string{"items", "*", "price"}, fn: round},
+			want:    `{"items":[{"price":1},{"price":3},{"name":"x"}]}`,
+			wantErr: false,
+		},
+		{
+			name:   "success - replace with a bjson value",
+			fields: fields{value: `{"a":{"x":1,"y":2}}`},
+			args: args{targets: []string{"a", "*"}, fn: func(current BJSON) (interface{}, error) {
+				return NewBJSON(`{"old":` + current.String() + `}`)
+			}},
+			want:    `{"a":{"x":{"old":1},"y":{"old":2}}}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - without wildcard",
+			fields:  fields{value: `{"a":1.5}`},
+			args:    args{targets: []string{"a"}, fn: round},
+			want:    `{"a":2}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - no match",
+			fields:  fields{value: `{"items":[]}`},
+			args:    args{targets: []string{"items", "*", "price"}, fn: round},
+			want:    `{"items":[]}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - fn error leaves document unchanged",
+			fields:  fields{value: `{"items":[{"price":1.4},{"price":"x"}]}`},
+			args:    args{targets: []string{"items", "*", "price"}, fn: round},
+			want:    `{"items":[{"price":1.4},{"price":"x"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"items", "*"}, fn: round},
+			want:    `{}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.UpdateAll(tt.args.targets, tt.args.fn)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_Detach(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantKey string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - detach nested subtree",
+			fields:  fields{value: `{"a":{"b.c":[{"x":1}]}}`},
+			args:    args{targets: []string{"a", "b.c", "0"}},
+			wantKey: `a.b\.c.0`,
+			want:    `{"x":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - detach root",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{targets: nil},
+			wantKey: "",
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			wantKey: "",
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			gotKey, got, err := bj.Detach(tt.args.targets...)
+			assert.Equal(t, tt.wantKey, gotKey)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+
+			// the detached copy is independent of the document
+			assert.NoError(t, got.AddElement(1, "new"))
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func TestQuoteSegment(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "success - plain key",
+			args: args{s: "a"},
+			want: "`a`",
+		},
+		{
+			name: "success - key with dot",
+			args: args{s: "a.b"},
+			want: "`a.b`",
+		},
+		{
+			name: "success - key with slash",
+			args: args{s: "a/b"},
+			want: "`a/b`",
+		},
+		{
+			name: "success - key with brackets",
+			args: args{s: "a[0]"},
+			want: "`a[0]`",
+		},
+		{
+			name: "success - key with backtick",
+			args: args{s: "a`b"},
+			want: "`a``b`",
+		},
+		{
+			name: "success - empty key",
+			args: args{s: ""},
+			want: "``",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := QuoteSegment(tt.args.s)
+			assert.Equal(t, tt.want, got)
+
+			unquoted, err := UnquoteSegment(got)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.args.s, unquoted)
+		})
+	}
+}
+
+func TestUnquoteSegment(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - quoted key",
+			args:    args{s: "`a.b`"},
+			want:    "a.b",
+			wantErr: false,
+		},
+		{
+			name:    "success - doubled backtick",
+			args:    args{s: "```a`"},
+			want:    "`a",
+			wantErr: false,
+		},
+		{
+			name:    "fail - not quoted",
+			args:    args{s: "a"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - unterminated",
+			args:    args{s: "`a"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - more than one segment",
+			args:    args{s: "`a`.`b`"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - lone backtick",
+			args:    args{s: "`"},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnquoteSegment(tt.args.s)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	type args struct {
+		path string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "success - dots and bracket index",
+			args:    args{path: "data.phone[0]"},
+			want:    []string{"data", "phone", "0"},
+			wantErr: false,
+		},
+		{
+			name:    "success - nested brackets",
+			args:    args{path: "a[0][1].b[2]"},
+			want:    []string{"a", "0", "1", "b", "2"},
+			wantErr: false,
+		},
+		{
+			name:    "success - leading bracket",
+			args:    args{path: "[3].x"},
+			want:    []string{"3", "x"},
+			wantErr: false,
+		},
+		{
+			name:    "success - escaped dot",
+			args:    args{path: `foo\.bar.baz`},
+			want:    []string{"foo.bar", "baz"},
+			wantErr: false,
+		},
+		{
+			name:    "success - empty path",
+			args:    args{path: ""},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "fail - trailing dot",
+			args:    args{path: "a.b."},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - leading dot",
+			args:    args{path: ".a"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - unbalanced open bracket",
+			args:    args{path: "a[0"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - unbalanced close bracket",
+			args:    args{path: "a0]"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - empty bracket",
+			args:    args{path: "a[]"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - trailing escape",
+			args:    args{path: `a\`},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePath(tt.args.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_ElementPath(t *testing.T) {
+	bj, err := NewBJSON(`{"data":{"phone":["1","2"],"a.b":true}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := bj.GetElementPath("data.phone[1]")
+	assert.NoError(t, err)
+	assert.Equal(t, `"2"`, got.String())
+
+	got, err = bj.GetElementPath(`data.a\.b`)
+	assert.NoError(t, err)
+	assert.Equal(t, `true`, got.String())
+
+	assert.NoError(t, bj.SetElementPath("x", "data.phone[0]"))
+	assert.NoError(t, bj.AddElementPath("3", "data.phone"))
+	assert.NoError(t, bj.RemoveElementPath(`data.a\.b`))
+	assert.Equal(t, `{"data":{"phone":["x","2","3"]}}`, bj.String())
+
+	_, err = bj.GetElementPath("data.")
+	assert.Error(t, err)
+	assert.Error(t, bj.SetElementPath("x", "data[0"))
+	assert.Error(t, bj.AddElementPath("x", "data..phone"))
+	assert.Error(t, bj.RemoveElementPath("data.missing"))
+	assert.Equal(t, `{"data":{"phone":["x","2","3"]}}`, bj.String())
+}
+
+func TestParsePath_quotedSegments(t *testing.T) {
+	type args struct {
+		path string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "success - key with dot",
+			args:    args{path: "a.`b.c`.d"},
+			want:    []string{"a", "b.c", "d"},
+			wantErr: false,
+		},
+		{
+			name:    "success - key with slash",
+			args:    args{path: "`/a/b`"},
+			want:    []string{"/a/b"},
+			wantErr: false,
+		},
+		{
+			name:    "success - key with bracket",
+			args:    args{path: "`a[0]`[1]"},
+			want:    []string{"a[0]", "1"},
+			wantErr: false,
+		},
+		{
+			name:    "success - empty key",
+			args:    args{path: "a.``"},
+			want:    []string{"a", ""},
+			wantErr: false,
+		},
+		{
+			name:    "success - backtick inside plain segment",
+			args:    args{path: "a`b"},
+			want:    []string{"a`b"},
+			wantErr: false,
+		},
+		{
+			name:    "success - quoted segments built by QuoteSegment",
+			args:    args{path: QuoteSegment("x.`y`") + "." + QuoteSegment("[z]")},
+			want:    []string{"x.`y`", "[z]"},
+			wantErr: false,
+		},
+		{
+			name:    "fail - unterminated quote",
+			args:    args{path: "a.`b"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - text after closing quote",
+			args:    args{path: "`a`b"},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePath(tt.args.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePath_formatPathRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []string
+	}{
+		{
+			name:    "success - plain keys and index",
+			targets: []string{"data", "phone", "0"},
+		},
+		{
+			name:    "success - keys with separators and brackets",
+			targets: []string{"a.b", "c[0]", "d]", `e\f`, "/g/h"},
+		},
+		{
+			name:    "success - keys starting with a backtick",
+			targets: []string{"`x`", "`", "``", "`a.b`"},
+		},
+		{
+			name:    "success - backtick inside keys",
+			targets: []string{"a`b", "c`"},
+		},
+		{
+			name:    "success - empty keys",
+			targets: []string{"", "a", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePath(formatPath(tt.targets))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.targets, got)
+		})
+	}
+}
+
+func Test_bjson_Index_keysResolve(t *testing.T) {
+	bj, err := NewBJSON(`{"` + "`x`" + `":{"a.b":[1]},"":{"` + "`" + `":true}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := bj.Index()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, index)
+	for key, want := range index {
+		got, err := bj.GetElementPath(key)
+		if assert.NoError(t, err, key) {
+			assert.Equal(t, want.String(), got.String(), key)
+		}
+	}
+}
+
+func Test_bjson_ConflictingPaths(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		paths [][]string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:   "success - same element and ancestor overlaps",
+			fields: fields{value: `{"a":{"b":1,"c":2},"d":[1,2],"e":1}`},
+			args: args{paths: [][]string{
+				{"a", "b"},
+				{"d", "0"},
+				{"a"},
+				{"e"},
+				{"d", "0"},
+				{"a", "c"},
+			}},
+			want:    [][]string{{"a.b", "a", "a.c"}, {"d.0", "d.0"}},
+			wantErr: false,
+		},
+		{
+			name:   "success - wildcard matches",
+			fields: fields{value: `{"items":[{"id":1},{"id":2}],"other":1}`},
+			args: args{paths: [][]string{
+				{"items", "*", "id"},
+				{"items", "1"},
+				{"other"},
+			}},
+			want:    [][]string{{"items.*.id", "items.1"}},
+			wantErr: false,
+		},
+		{
+			name:   "success - negative index overlaps absolute index",
+			fields: fields{value: `{"a":[1,2,3]}`},
+			args: args{paths: [][]string{
+				{"a", "-1"},
+				{"a", "0"},
+				{"a", "2"},
+			}},
+			want:    [][]string{{"a.-1", "a.2"}},
+			wantErr: false,
+		},
+		{
+			name:   "success - unresolved paths compared as written",
+			fields: fields{value: `{"a":{}}`},
+			args: args{paths: [][]string{
+				{"a", "new", "x"},
+				{"a", "new"},
+				{"a", "other"},
+			}},
+			want:    [][]string{{"a.new.x", "a.new"}},
+			wantErr: false,
+		},
+		{
+			name:    "success - no conflicts",
+			fields:  fields{value: `{"a":1,"b":2}`},
+			args:    args{paths: [][]string{{"a"}, {"b"}}},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "fail - wildcard path does not resolve",
+			fields:  fields{value: `{}`},
+			args:    args{paths: [][]string{{"items", "*"}}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.ConflictingPaths(tt.args.paths)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
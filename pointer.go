@@ -0,0 +1,135 @@
+package bjson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetPointers sets the value of every RFC 6901 JSON Pointer key of ops, in sorted order, with
+// SetElement semantics. Either every operation is applied or, when any pointer is invalid or
+// does not resolve, none is.
+func (bj *bjson) SetPointers(ops map[string]interface{}) error {
+	pointers := make([]string, 0, len(ops))
+	for pointer := range ops {
+		pointers = append(pointers, pointer)
+	}
+	sort.Strings(pointers)
+
+	return bj.atomic(func(scratch *bjson) error {
+		for _, pointer := range pointers {
+			targets, err := parsePointer(pointer)
+			if err != nil {
+				return err
+			}
+
+			if err = scratch.SetElement(ops[pointer], targets...); err != nil {
+				return fmt.Errorf("fail to set pointer '%v'. %v", pointer, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+const pointerSeparator = '/'
+
+// GetPointer returns the element at an RFC 6901 JSON Pointer such as "/data/phone/0".
+func (bj *bjson) GetPointer(pointer string) (BJSON, error) {
+	targets, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	return bj.GetElement(targets...)
+}
+
+// SetPointer sets the element at an RFC 6901 JSON Pointer with SetElement semantics, so a last
+// token "-" whose parent is an array appends value to that array.
+func (bj *bjson) SetPointer(value interface{}, pointer string) error {
+	targets, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+
+	return bj.SetElement(value, targets...)
+}
+
+// RemovePointer removes the element at an RFC 6901 JSON Pointer.
+func (bj *bjson) RemovePointer(pointer string) error {
+	targets, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+
+	return bj.RemoveElement(targets...)
+}
+
+// GetFlexible returns the element at path, which is either a dot-path (see ParsePath) or an
+// RFC 6901 JSON Pointer. A path starting with '/' is parsed as a JSON Pointer and any other
+// path as a dot-path. Passing '/' or '.' as sep forces that syntax regardless of the first
+// character; only the first sep is used.
+func (bj *bjson) GetFlexible(path string, sep ...rune) (BJSON, error) {
+	isPointer := strings.HasPrefix(path, string(pointerSeparator))
+	if len(sep) > 0 {
+		switch sep[0] {
+		case pointerSeparator:
+			isPointer = true
+		case pathSeparator:
+			isPointer = false
+		default:
+			return nil, fmt.Errorf("invalid separator '%c': separator must be '%c' or '%c'", sep[0], pathSeparator, pointerSeparator)
+		}
+	}
+
+	var (
+		targets []string
+		err     error
+	)
+	if isPointer {
+		targets, err = parsePointer(path)
+	} else {
+		targets, err = ParsePath(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return bj.GetElement(targets...)
+}
+
+// parsePointer converts an RFC 6901 JSON Pointer such as "/data/phone/0" into targets,
+// decoding "~1" to "/" and "~0" to "~". The empty pointer addresses the root element.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if pointer[0] != pointerSeparator {
+		return nil, fmt.Errorf("invalid pointer '%v': pointer must start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], string(pointerSeparator))
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 >= len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("invalid pointer '%v': invalid escape sequence in token '%v'", pointer, token)
+			}
+		}
+
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// formatPointer is the inverse of parsePointer.
+func formatPointer(targets []string) string {
+	var sb strings.Builder
+	for _, target := range targets {
+		sb.WriteRune(pointerSeparator)
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(target, "~", "~0"), "/", "~1"))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,408 @@
+package bjson
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_bjson_SetPointers(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		ops map[string]interface{}
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "success - set many pointers",
+			fields: fields{value: `{"a":{"b":1},"arr":[1,2],"c/d":0,"e~f":0}`},
+			args: args{ops: map[string]interface{}{
+				"/a/b":   "x",
+				"/arr/1": []interface{}{true},
+				"/c~1d":  1,
+				"/e~0f":  2,
+			}},
+			want:    `{"a":{"b":"x"},"arr":[1,[true]],"c/d":1,"e~f":2}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - set root",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{ops: map[string]interface{}{"": []interface{}{1}}},
+			want:    `[1]`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - invalid pointer",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{ops: map[string]interface{}{"/a": 2, "a": 3}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - invalid escape sequence",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{ops: map[string]interface{}{"/a": 2, "/b~2": 3}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - unresolved pointer aborts the batch",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{ops: map[string]interface{}{"/a": 2, "/b/c": 3}},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.SetPointers(tt.args.ops)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_GetFlexible(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		path string
+		sep  []rune
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - detect dot-path",
+			fields:  fields{value: `{"a":{"b":[1,{"c":"x"}]}}`},
+			args:    args{path: "a.b[1].c", sep: nil},
+			want:    `"x"`,
+			wantErr: false,
+		},
+		{
+			name:    "success - detect pointer",
+			fields:  fields{value: `{"a":{"b":[1,{"c":"x"}]}}`},
+			args:    args{path: "/a/b/1/c", sep: nil},
+			want:    `"x"`,
+			wantErr: false,
+		},
+		{
+			name:    "success - empty path is root",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{path: "", sep: nil},
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - force dot-path for key starting with slash",
+			fields:  fields{value: `{"/a":{"b":1}}`},
+			args:    args{path: "/a.b", sep: []rune{'.'}},
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			name:    "success - force pointer for key containing dot",
+			fields:  fields{value: `{"a.b":{"c":2}}`},
+			args:    args{path: "/a.b/c", sep: []rune{'/'}},
+			want:    `2`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - forced pointer without leading slash",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{path: "a", sep: []rune{'/'}},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - invalid separator",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{path: "a", sep: []rune{':'}},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{path: "/b", sep: nil},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.GetFlexible(tt.args.path, tt.args.sep...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func Test_bjson_GetPointer(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		pointer string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - array index",
+			fields:  fields{value: `{"data":{"phone":["1","2"]}}`},
+			args:    args{pointer: "/data/phone/1"},
+			want:    `"2"`,
+			wantErr: false,
+		},
+		{
+			name:    "success - escaped keys",
+			fields:  fields{value: `{"a/b":{"c~d":1}}`},
+			args:    args{pointer: "/a~1b/c~0d"},
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			name:    "success - empty key",
+			fields:  fields{value: `{"":{"":2}}`},
+			args:    args{pointer: "//"},
+			want:    `2`,
+			wantErr: false,
+		},
+		{
+			name:    "success - empty pointer addresses the document",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{pointer: ""},
+			want:    `{"a":1}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - missing leading slash",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{pointer: "a"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - invalid escape sequence",
+			fields:  fields{value: `{"a~":1}`},
+			args:    args{pointer: "/a~"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - end of array token",
+			fields:  fields{value: `[1]`},
+			args:    args{pointer: "/-"},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.GetPointer(tt.args.pointer)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func Test_bjson_SetPointer(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		value   interface{}
+		pointer string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - set array index",
+			fields:  fields{value: `{"a":[1,2]}`},
+			args:    args{value: "x", pointer: "/a/0"},
+			want:    `{"a":["x",2]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - set escaped key",
+			fields:  fields{value: `{"a/b":1}`},
+			args:    args{value: 2, pointer: "/a~1b"},
+			want:    `{"a/b":2}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - end of array token appends",
+			fields:  fields{value: `{"a":[1,2]}`},
+			args:    args{value: 3, pointer: "/a/-"},
+			want:    `{"a":[1,2,3]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - end of array token on root array",
+			fields:  fields{value: `[]`},
+			args:    args{value: true, pointer: "/-"},
+			want:    `[true]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - dash is a plain key in objects",
+			fields:  fields{value: `{"-":1}`},
+			args:    args{value: 2, pointer: "/-"},
+			want:    `{"-":2}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - empty pointer replaces the document",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{value: []interface{}{1}, pointer: ""},
+			want:    `[1]`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - invalid pointer",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{value: 2, pointer: "a"},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{value: 2, pointer: "/b/-"},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.SetPointer(tt.args.value, tt.args.pointer)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
+
+func Test_bjson_RemovePointer(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		pointer string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - remove array index",
+			fields:  fields{value: `{"a":[1,2,3]}`},
+			args:    args{pointer: "/a/1"},
+			want:    `{"a":[1,3]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - remove escaped key",
+			fields:  fields{value: `{"a~b":1,"c":2}`},
+			args:    args{pointer: "/a~0b"},
+			want:    `{"c":2}`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - invalid escape sequence",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{pointer: "/~x"},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{pointer: "/b"},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.RemovePointer(tt.args.pointer)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
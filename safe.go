@@ -0,0 +1,46 @@
+package bjson
+
+import (
+	"sync"
+)
+
+// SafeBJSON guards a BJSON with a read-write mutex so it can be shared between goroutines.
+// The BJSON passed to View and Update callbacks must not be retained after they return.
+type SafeBJSON struct {
+	mu sync.RWMutex
+	bj BJSON
+}
+
+func NewSafeBJSON(bj BJSON) *SafeBJSON {
+	return &SafeBJSON{bj: bj}
+}
+
+func (s *SafeBJSON) View(fn func(bj BJSON) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn(s.bj)
+}
+
+func (s *SafeBJSON) Update(fn func(bj BJSON) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fn(s.bj)
+}
+
+// Copy returns a consistent deep copy of the document. Writers are blocked until the copy
+// is taken, so it never observes a partially applied Update.
+func (s *SafeBJSON) Copy() (BJSON, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.bj.Copy()
+}
+
+func (s *SafeBJSON) String() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.bj.String()
+}
@@ -0,0 +1,111 @@
+package bjson
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSafeBJSON_Copy(t *testing.T) {
+	bj, err := NewBJSON(`{"counter":0,"items":{}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sbj := NewSafeBJSON(bj)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				err := sbj.Update(func(bj BJSON) error {
+					// the writers keep counter equal to the number of items
+					counter, err := bj.GetInt("counter")
+					if err != nil {
+						return err
+					}
+
+					if err = bj.SetElement(counter+1, "counter"); err != nil {
+						return err
+					}
+
+					return bj.AddElement(j, "items", fmt.Sprintf("%v_%v", i, j))
+				})
+				assert.NoError(t, err)
+			}
+		}(i)
+
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				got, err := sbj.Copy()
+				assert.NoError(t, err)
+
+				counter, err := got.GetInt("counter")
+				assert.NoError(t, err)
+				items, err := got.GetElement("items")
+				assert.NoError(t, err)
+				assert.Equal(t, int(counter), items.Len())
+
+				// the copy must stay untouched by the writers running concurrently
+				before := got.String()
+				runtime.Gosched()
+				assert.Equal(t, before, got.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	err = sbj.View(func(bj BJSON) error {
+		items, err := bj.GetElement("items")
+		if err != nil {
+			return err
+		}
+
+		assert.Equal(t, 200, items.Len())
+
+		counter, err := bj.GetInt("counter")
+		if err != nil {
+			return err
+		}
+
+		assert.Equal(t, int64(200), counter)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestSafeBJSON_View(t *testing.T) {
+	bj, err := NewBJSON(`{"a":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sbj := NewSafeBJSON(bj)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, sbj.Update(func(bj BJSON) error {
+				return bj.AddElement(i, "k"+strconv.Itoa(i))
+			}))
+		}(i)
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, sbj.View(func(bj BJSON) error {
+				_, err := bj.GetElement("a")
+				return err
+			}))
+			assert.NotEmpty(t, sbj.String())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, `{"a":1,"k0":0,"k1":1,"k2":2,"k3":3}`, sbj.String())
+}
@@ -0,0 +1,262 @@
+package bjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Schema describes the structure of the element at targets. JSON objects map their keys to
+// the schema of each child, JSON arrays hold the schema of their elements merged into a
+// single one and leaves are replaced by their JSONType name. When merged elements disagree,
+// object keys are united and differing types are joined with "|" in sorted order,
+// e.g. "null|string".
+func (bj *bjson) Schema(targets ...string) (BJSON, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return nil, err
+	}
+
+	return &bjson{value: schemaOf(sel.value)}, nil
+}
+
+func schemaOf(value interface{}) interface{} {
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(obj))
+		for key, child := range obj {
+			ret[key] = schemaOf(child)
+		}
+
+		return ret
+
+	case []interface{}:
+		if len(obj) == 0 {
+			return []interface{}{}
+		}
+
+		elem := schemaOf(obj[0])
+		for _, child := range obj[1:] {
+			elem = mergeSchemas(elem, schemaOf(child))
+		}
+
+		return []interface{}{elem}
+	}
+
+	return string(typeOf(value))
+}
+
+func mergeSchemas(a, b interface{}) interface{} {
+	aObj, isAObj := a.(map[string]interface{})
+	bObj, isBObj := b.(map[string]interface{})
+	if isAObj && isBObj {
+		for key, child := range bObj {
+			if aChild, isExist := aObj[key]; isExist {
+				child = mergeSchemas(aChild, child)
+			}
+
+			aObj[key] = child
+		}
+
+		return aObj
+	}
+
+	aArr, isAArr := a.([]interface{})
+	bArr, isBArr := b.([]interface{})
+	if isAArr && isBArr {
+		switch {
+		case len(aArr) == 0:
+			return bArr
+		case len(bArr) == 0:
+			return aArr
+		}
+
+		return []interface{}{mergeSchemas(aArr[0], bArr[0])}
+	}
+
+	names := make(map[string]bool)
+	for _, name := range append(schemaTypeNames(a), schemaTypeNames(b)...) {
+		names[name] = true
+	}
+
+	ret := make([]string, 0, len(names))
+	for name := range names {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+
+	return strings.Join(ret, "|")
+}
+
+func schemaTypeNames(schema interface{}) []string {
+	switch obj := schema.(type) {
+	case map[string]interface{}:
+		return []string{string(JSONTypeObject)}
+	case []interface{}:
+		return []string{string(JSONTypeArray)}
+	case string:
+		return strings.Split(obj, "|")
+	}
+
+	return nil
+}
+
+// Sample builds a minimal document valid against a JSON Schema using this subset of keywords:
+// "const" and the first "enum" value win over everything else; otherwise "type" (the first
+// one when it is a list) selects the value. Objects hold only their "required" properties,
+// sampled from "properties"; arrays hold "minItems" samples of "items"; strings hold
+// "minLength" times "x"; numbers and integers are 0 moved into ["minimum", "maximum"] and
+// rounded up for integers; booleans are false. Without "type", a schema with "properties"
+// is an object, one with "items" an array and any other yields null. A "minItems" or
+// "minLength" that is not an integer between 0 and 65536 fails.
+func Sample(schema []byte) (BJSON, error) {
+	var s interface{}
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, decodeError(schema, err)
+	}
+
+	value, err := sampleSchema(nil, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bjson{value: value}, nil
+}
+
+func sampleSchema(path []string, schema interface{}) (interface{}, error) {
+	obj, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema at path '%v' is not a json object. got: %T", formatPath(path), schema)
+	}
+
+	if value, isExist := obj["const"]; isExist {
+		return value, nil
+	}
+
+	if enum, ok := obj["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0], nil
+	}
+
+	typ := obj["type"]
+	if types, ok := typ.([]interface{}); ok && len(types) > 0 {
+		typ = types[0]
+	}
+
+	if typ == nil {
+		switch {
+		case obj["properties"] != nil:
+			typ = string(JSONTypeObject)
+		case obj["items"] != nil:
+			typ = string(JSONTypeArray)
+		default:
+			typ = string(JSONTypeNull)
+		}
+	}
+
+	switch typ {
+	case string(JSONTypeObject):
+		properties, _ := obj["properties"].(map[string]interface{})
+		required, _ := obj["required"].([]interface{})
+		ret := make(map[string]interface{}, len(required))
+		for _, v := range required {
+			key, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("schema at path '%v' has a non-string required key. got: %T", formatPath(path), v)
+			}
+
+			propSchema, isExist := properties[key]
+			if !isExist {
+				propSchema = map[string]interface{}{}
+			}
+
+			child, err := sampleSchema(appendPath(path, "properties", key), propSchema)
+			if err != nil {
+				return nil, err
+			}
+
+			ret[key] = child
+		}
+
+		return ret, nil
+
+	case string(JSONTypeArray):
+		items, isExist := obj["items"]
+		if !isExist {
+			items = map[string]interface{}{}
+		}
+
+		minItems, err := schemaCount(path, obj, "minItems")
+		if err != nil {
+			return nil, err
+		}
+
+		ret := make([]interface{}, 0, minItems)
+		for i := 0; i < minItems; i++ {
+			child, err := sampleSchema(appendPath(path, "items"), items)
+			if err != nil {
+				return nil, err
+			}
+
+			ret = append(ret, child)
+		}
+
+		return ret, nil
+
+	case string(JSONTypeString):
+		minLength, err := schemaCount(path, obj, "minLength")
+		if err != nil {
+			return nil, err
+		}
+
+		return strings.Repeat("x", minLength), nil
+
+	case string(JSONTypeNumber), "integer":
+		minimum, maximum := schemaNumber(obj, "minimum", math.Inf(-1)), schemaNumber(obj, "maximum", math.Inf(1))
+		ret := math.Min(math.Max(0, minimum), maximum)
+		if typ != "integer" || ret == math.Trunc(ret) {
+			return ret, nil
+		}
+
+		if ceil := math.Ceil(ret); ceil <= maximum {
+			return ceil, nil
+		}
+
+		if floor := math.Floor(ret); floor >= minimum {
+			return floor, nil
+		}
+
+		return nil, fmt.Errorf("schema at path '%v' has no integer between minimum %v and maximum %v", formatPath(path), minimum, maximum)
+
+	case string(JSONTypeBool):
+		return false, nil
+
+	case string(JSONTypeNull):
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("schema at path '%v' has an unsupported type: %v", formatPath(path), typ)
+}
+
+// maxSampleCount caps "minItems" and "minLength" so a hostile schema cannot make Sample
+// allocate without bound.
+const maxSampleCount = 1 << 16
+
+// schemaCount returns the non-negative integer keyword key of obj, 0 when it is missing.
+func schemaCount(path []string, obj map[string]interface{}, key string) (int, error) {
+	num := schemaNumber(obj, key, 0)
+	if num < 0 || num > maxSampleCount || num != math.Trunc(num) {
+		return 0, fmt.Errorf("schema at path '%v' has invalid %v %v. must be an integer between 0 and %v", formatPath(path), key, num, maxSampleCount)
+	}
+
+	return int(num), nil
+}
+
+func schemaNumber(obj map[string]interface{}, key string, fallback float64) float64 {
+	if num, ok := obj[key].(float64); ok {
+		return num
+	}
+
+	return fallback
+}
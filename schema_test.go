@@ -0,0 +1,195 @@
+package bjson
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_bjson_Schema(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - describe nested structure",
+			fields:  fields{value: `{"id":1,"name":"a","tags":["x","y"],"meta":{"ok":true,"note":null},"empty":[]}`},
+			args:    args{targets: nil},
+			want:    `{"empty":[],"id":"number","meta":{"note":"null","ok":"boolean"},"name":"string","tags":["string"]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - merge array element schemas",
+			fields:  fields{value: `[{"a":1,"b":"x"},{"a":"2","c":[1]},{"c":[],"d":{"e":null}},{"d":{"e":"s"}}]`},
+			args:    args{targets: nil},
+			want:    `[{"a":"number|string","b":"string","c":["number"],"d":{"e":"null|string"}}]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - merge objects with scalars",
+			fields:  fields{value: `{"arr":[{"a":1},"x",null,[1]]}`},
+			args:    args{targets: []string{"arr"}},
+			want:    `["array|null|object|string"]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - scalar root",
+			fields:  fields{value: `12`},
+			args:    args{targets: nil},
+			want:    `"number"`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{targets: []string{"a"}},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			got, err := bj.Schema(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got.String())
+			}
+
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func TestSample(t *testing.T) {
+	type args struct {
+		schema string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "success - required fields with zero values",
+			args: args{schema: `{
+				"type": "object",
+				"required": ["id", "name", "active", "tags", "role", "meta", "score", "nick"],
+				"properties": {
+					"id": {"type": "integer", "minimum": 1.5},
+					"name": {"type": "string", "minLength": 3},
+					"active": {"type": "boolean"},
+					"tags": {"type": "array", "minItems": 2, "items": {"type": "string"}},
+					"role": {"type": "string", "enum": ["admin", "user"]},
+					"meta": {"properties": {"v": {"const": 2}}, "required": ["v"]},
+					"score": {"type": ["number", "null"], "maximum": -2.5},
+					"optional": {"type": "string"}
+				}
+			}`},
+			want:    `{"active":false,"id":2,"meta":{"v":2},"name":"xxx","nick":null,"role":"admin","score":-2.5,"tags":["",""]}`,
+			wantErr: false,
+		},
+		{
+			name:    "success - array without items schema",
+			args:    args{schema: `{"type":"array","minItems":1}`},
+			want:    `[null]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - empty schema",
+			args:    args{schema: `{}`},
+			want:    `null`,
+			wantErr: false,
+		},
+		{
+			name:    "success - integer below a fractional maximum",
+			args:    args{schema: `{"type":"integer","maximum":-0.5}`},
+			want:    `-1`,
+			wantErr: false,
+		},
+		{
+			name:    "success - integer within a fractional range",
+			args:    args{schema: `{"type":"integer","minimum":0.5,"maximum":1.7}`},
+			want:    `1`,
+			wantErr: false,
+		},
+		{
+			name:    "fail - no integer within range",
+			args:    args{schema: `{"type":"object","required":["a"],"properties":{"a":{"type":"integer","minimum":0.5,"maximum":0.7}}}`},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - negative minLength",
+			args:    args{schema: `{"type":"object","required":["a"],"properties":{"a":{"type":"string","minLength":-1}}}`},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - negative minItems",
+			args:    args{schema: `{"type":"array","minItems":-1}`},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - too large minItems",
+			args:    args{schema: `{"type":"array","minItems":1e12}`},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - fractional minLength",
+			args:    args{schema: `{"type":"string","minLength":1.5}`},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - unsupported type",
+			args:    args{schema: `{"type":"object","required":["a"],"properties":{"a":{"type":"date"}}}`},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - schema is not an object",
+			args:    args{schema: `[]`},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "fail - invalid json",
+			args:    args{schema: `{`},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Sample([]byte(tt.args.schema))
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package bjson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ArrayWriter streams a top-level JSON array to an io.Writer one element at a time, so
+// large arrays never have to be held in memory. The output matches Marshal of the same array.
+type ArrayWriter struct {
+	w        io.Writer
+	isPretty bool
+	count    int
+	closed   bool
+}
+
+func NewArrayWriter(w io.Writer, isPretty bool) *ArrayWriter {
+	return &ArrayWriter{w: w, isPretty: isPretty}
+}
+
+func (aw *ArrayWriter) Append(value interface{}) error {
+	if aw.closed {
+		return errors.New("cannot append to a closed array writer")
+	}
+
+	data, err := marshalCompact(value)
+	if err != nil {
+		return err
+	}
+
+	buff := bytes.NewBuffer(nil)
+	if aw.count == 0 {
+		buff.WriteString("[")
+	} else {
+		buff.WriteString(",")
+	}
+
+	if aw.isPretty {
+		buff.WriteString("\n\t")
+		if err = json.Indent(buff, data, "\t", "\t"); err != nil {
+			return err
+		}
+	} else {
+		buff.Write(data)
+	}
+
+	if _, err = aw.w.Write(buff.Bytes()); err != nil {
+		return err
+	}
+
+	aw.count++
+	return nil
+}
+
+func (aw *ArrayWriter) Close() error {
+	if aw.closed {
+		return nil
+	}
+	aw.closed = true
+
+	closing := "]"
+	switch {
+	case aw.count == 0:
+		closing = "[]"
+	case aw.isPretty:
+		closing = "\n]"
+	}
+
+	_, err := io.WriteString(aw.w, closing)
+	return err
+}
+
+func marshalCompact(value interface{}) ([]byte, error) {
+	if bj, ok := value.(BJSON); ok {
+		return bj.Marshal(false)
+	}
+
+	return json.Marshal(value)
+}
+
+const progressChunkSize = 32 << 10
+
+// progressWriter forwards writes to w in chunks of at most progressChunkSize and reports the
+// running total after every chunk. A trailing newline is held back until the next write, so
+// the newline json.Encoder ends every value with is never written.
+type progressWriter struct {
+	w              io.Writer
+	onBytes        func(written int64)
+	written        int64
+	pendingNewline bool
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if pw.pendingNewline {
+		if err := pw.writeChunks([]byte{'\n'}); err != nil {
+			return 0, err
+		}
+		pw.pendingNewline = false
+	}
+
+	data := p
+	if data[len(data)-1] == '\n' {
+		data = data[:len(data)-1]
+		pw.pendingNewline = true
+	}
+
+	if err := pw.writeChunks(data); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (pw *progressWriter) writeChunks(data []byte) error {
+	for len(data) > 0 {
+		n := progressChunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+
+		m, err := pw.w.Write(data[:n])
+		pw.written += int64(m)
+		if pw.onBytes != nil && m > 0 {
+			pw.onBytes(pw.written)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		data = data[n:]
+	}
+
+	return nil
+}
+
+// MarshalToProgress encodes the element at targets to w like Marshal, in chunks of at most
+// 32 KiB, and calls onBytes with the total number of bytes written after every chunk. A nil
+// onBytes only writes. A failed write stops the export and is returned.
+func (bj *bjson) MarshalToProgress(w io.Writer, isPretty bool, onBytes func(written int64), targets ...string) error {
+	sel, err := bj.getMarshalElement(targets)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(&progressWriter{w: w, onBytes: onBytes})
+	if isPretty {
+		enc.SetIndent("", "\t")
+	}
+
+	return enc.Encode(sel.value)
+}
+
+// TransformNDJSON reads newline-delimited JSON from r one line at a time, passes every line
+// to fn and writes the returned document as a compact line to w. Blank lines are skipped and
+// returning a nil BJSON from fn drops the line. Any error stops the transformation.
+func TransformNDJSON(r io.Reader, w io.Writer, fn func(BJSON) (BJSON, error)) error {
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if err := transformNDJSONLine(line, w, fn); err != nil {
+				return fmt.Errorf("fail to transform line %v. %v", lineNo, err)
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+func transformNDJSONLine(line []byte, w io.Writer, fn func(BJSON) (BJSON, error)) error {
+	bj, err := NewBJSON(line)
+	if err != nil {
+		return err
+	}
+
+	out, err := fn(bj)
+	if err != nil || out == nil {
+		return err
+	}
+
+	data, err := out.Marshal(false)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// StreamValues reads the successive top-level JSON values of r, which may follow each other
+// without any separator such as {...}{...}[...], and passes each of them to fn in order.
+// Malformed input or an error returned by fn stops the consumption.
+func StreamValues(r io.Reader, fn func(BJSON) error) error {
+	dec := json.NewDecoder(r)
+	valueNo := 1
+	for ; dec.More(); valueNo++ {
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return fmt.Errorf("fail to decode value %v. %v", valueNo, err)
+		}
+
+		if err := fn(&bjson{value: value}); err != nil {
+			return fmt.Errorf("fail to process value %v. %v", valueNo, err)
+		}
+	}
+
+	// More also stops at a stray closing delimiter, so the input must be fully consumed
+	if tok, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("unexpected token %v", tok)
+		}
+
+		return fmt.Errorf("fail to decode value %v. %v", valueNo, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,331 @@
+package bjson
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestArrayWriter(t *testing.T) {
+	sub, err := NewBJSON(`{"b":[1,2]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		isPretty bool
+		values   []interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success - empty array",
+			args:    args{isPretty: false, values: nil},
+			want:    `[]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - empty pretty array",
+			args:    args{isPretty: true, values: nil},
+			want:    `[]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - compact array",
+			args:    args{isPretty: false, values: []interface{}{1, "x", map[string]interface{}{"a": true}, sub}},
+			want:    `[1,"x",{"a":true},{"b":[1,2]}]`,
+			wantErr: false,
+		},
+		{
+			name:    "success - pretty array",
+			args:    args{isPretty: true, values: []interface{}{1, map[string]interface{}{"a": []interface{}{true}}}},
+			want:    "[\n\t1,\n\t{\n\t\t\"a\": [\n\t\t\ttrue\n\t\t]\n\t}\n]",
+			wantErr: false,
+		},
+		{
+			name:    "fail - value cannot be marshaled",
+			args:    args{isPretty: false, values: []interface{}{func() {}}},
+			want:    ``,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buff := bytes.NewBuffer(nil)
+			aw := NewArrayWriter(buff, tt.args.isPretty)
+			for _, v := range tt.args.values {
+				if err := aw.Append(v); err != nil {
+					assert.True(t, tt.wantErr)
+					return
+				}
+			}
+			assert.False(t, tt.wantErr)
+			assert.NoError(t, aw.Close())
+			assert.Equal(t, tt.want, buff.String())
+
+			// the stream must match marshaling the whole array at once
+			if len(tt.args.values) > 0 {
+				bj, err := NewBJSON(buff.Bytes())
+				assert.NoError(t, err)
+				want, err := bj.Marshal(tt.args.isPretty)
+				assert.NoError(t, err)
+				assert.Equal(t, string(want), buff.String())
+			}
+
+			assert.Error(t, aw.Append(1))
+		})
+	}
+}
+
+func TestTransformNDJSON(t *testing.T) {
+	type args struct {
+		input string
+		fn    func(BJSON) (BJSON, error)
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "success - transform and drop lines",
+			args: args{
+				input: "{\"level\":\"info\",\"msg\":\"a\"}\n\n{\"level\":\"debug\",\"msg\":\"b\"}\r\n{\"level\":\"error\", \"msg\":\"c\"}",
+				fn: func(bj BJSON) (BJSON, error) {
+					level, err := bj.GetElement("level")
+					if err != nil {
+						return nil, err
+					}
+
+					if level.String() == `"debug"` {
+						return nil, nil
+					}
+
+					return bj, bj.RemoveElement("level")
+				},
+			},
+			want:    "{\"msg\":\"a\"}\n{\"msg\":\"c\"}\n",
+			wantErr: false,
+		},
+		{
+			name: "success - empty input",
+			args: args{
+				input: "",
+				fn:    func(bj BJSON) (BJSON, error) { return bj, nil },
+			},
+			want:    "",
+			wantErr: false,
+		},
+		{
+			name: "fail - invalid line",
+			args: args{
+				input: "{\"a\":1}\n{invalid}\n{\"a\":2}\n",
+				fn:    func(bj BJSON) (BJSON, error) { return bj, nil },
+			},
+			want:    "{\"a\":1}\n",
+			wantErr: true,
+		},
+		{
+			name: "fail - transform error aborts",
+			args: args{
+				input: "{\"a\":1}\n{\"b\":2}\n",
+				fn: func(bj BJSON) (BJSON, error) {
+					_, err := bj.GetElement("a")
+					return bj, err
+				},
+			},
+			want:    "{\"a\":1}\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buff := bytes.NewBuffer(nil)
+			err := TransformNDJSON(strings.NewReader(tt.args.input), buff, tt.args.fn)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, buff.String())
+		})
+	}
+}
+
+type failingWriter struct {
+	limit int
+}
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > fw.limit {
+		return fw.limit, errors.New("write failed")
+	}
+
+	fw.limit -= len(p)
+	return len(p), nil
+}
+
+func Test_bjson_MarshalToProgress(t *testing.T) {
+	large := `{"data":"` + strings.Repeat("x", 70000) + `"}`
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		w        io.Writer
+		isPretty bool
+		targets  []string
+	}
+	tests := []struct {
+		name         string
+		fields       fields
+		args         args
+		want         string
+		wantProgress []int64
+		wantErr      bool
+	}{
+		{
+			name:         "success - report every chunk",
+			fields:       fields{value: large},
+			args:         args{w: &bytes.Buffer{}, isPretty: false, targets: nil},
+			want:         large,
+			wantProgress: []int64{32768, 65536, 70011},
+			wantErr:      false,
+		},
+		{
+			name:         "success - pretty target",
+			fields:       fields{value: `{"a":{"b":1}}`},
+			args:         args{w: &bytes.Buffer{}, isPretty: true, targets: []string{"a"}},
+			want:         "{\n\t\"b\": 1\n}",
+			wantProgress: []int64{11},
+			wantErr:      false,
+		},
+		{
+			name:         "fail - writer error",
+			fields:       fields{value: large},
+			args:         args{w: &failingWriter{limit: 40000}, isPretty: false, targets: nil},
+			wantProgress: []int64{32768, 40000},
+			wantErr:      true,
+		},
+		{
+			name:         "fail - element is not found",
+			fields:       fields{value: `{}`},
+			args:         args{w: &bytes.Buffer{}, isPretty: false, targets: []string{"a"}},
+			want:         "",
+			wantProgress: nil,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotProgress []int64
+			err = bj.MarshalToProgress(tt.args.w, tt.args.isPretty, func(written int64) {
+				gotProgress = append(gotProgress, written)
+			}, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, tt.args.w.(*bytes.Buffer).String())
+			}
+
+			assert.Equal(t, tt.wantProgress, gotProgress)
+		})
+	}
+}
+
+func TestStreamValues(t *testing.T) {
+	type args struct {
+		r  io.Reader
+		fn func(got *[]string) func(BJSON) error
+	}
+	collect := func(got *[]string) func(BJSON) error {
+		return func(bj BJSON) error {
+			*got = append(*got, bj.String())
+			return nil
+		}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "success - concatenated values",
+			args:    args{r: strings.NewReader(`{"a":1}{"b":[2]}[3,4]"x"  12 true null`), fn: collect},
+			want:    []string{`{"a":1}`, `{"b":[2]}`, `[3,4]`, `"x"`, `12`, `true`, `null`},
+			wantErr: false,
+		},
+		{
+			name:    "success - whitespace separated values",
+			args:    args{r: strings.NewReader("{\"a\":1}\n\n {\"a\":2}\n"), fn: collect},
+			want:    []string{`{"a":1}`, `{"a":2}`},
+			wantErr: false,
+		},
+		{
+			name:    "success - empty input",
+			args:    args{r: strings.NewReader("  "), fn: collect},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "fail - stray closing brace",
+			args:    args{r: strings.NewReader(`{} }`), fn: collect},
+			want:    []string{`{}`},
+			wantErr: true,
+		},
+		{
+			name:    "fail - stray closing bracket",
+			args:    args{r: strings.NewReader(`{"a":1}{"b":2}]`), fn: collect},
+			want:    []string{`{"a":1}`, `{"b":2}`},
+			wantErr: true,
+		},
+		{
+			name:    "fail - malformed value",
+			args:    args{r: strings.NewReader(`{"a":1}{"b":}`), fn: collect},
+			want:    []string{`{"a":1}`},
+			wantErr: true,
+		},
+		{
+			name: "fail - fn stops consumption",
+			args: args{r: strings.NewReader(`1 2 3`), fn: func(got *[]string) func(BJSON) error {
+				return func(bj BJSON) error {
+					*got = append(*got, bj.String())
+					if len(*got) == 2 {
+						return errors.New("stop")
+					}
+					return nil
+				}
+			}},
+			want:    []string{`1`, `2`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := StreamValues(tt.args.r, tt.args.fn(&got))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
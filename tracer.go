@@ -44,6 +44,14 @@ func (t *tracer) passedPath() string {
 	return parseTracerPath(t.passed)
 }
 
+func (t *tracer) parentPath() string {
+	if len(t.passed) == 0 {
+		return parseTracerPath(nil)
+	}
+
+	return parseTracerPath(t.passed[:len(t.passed)-1])
+}
+
 func (t *tracer) originPath() string {
 	return parseTracerPath(t.origin)
 }
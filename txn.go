@@ -0,0 +1,126 @@
+package bjson
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Txn buffers operations against a document. Commit applies them in order to a copy of the
+// document and replaces the document only when all of them succeed; Rollback discards them.
+// A Txn cannot be reused once committed or rolled back.
+type Txn interface {
+	Add(value interface{}, targets ...string)
+	Set(value interface{}, targets ...string)
+	Remove(targets ...string)
+	Escape(targets ...string)
+
+	Commit() error
+	Rollback()
+}
+
+type txn struct {
+	bj       *bjson
+	ops      []func(scratch *bjson) error
+	err      error
+	isClosed bool
+}
+
+func (bj *bjson) Begin() Txn {
+	return &txn{bj: bj}
+}
+
+func (t *txn) Add(value interface{}, targets ...string) {
+	t.recordValue(value, func(scratch *bjson, value interface{}, targets []string) error {
+		return scratch.AddElement(value, targets...)
+	}, targets)
+}
+
+func (t *txn) Set(value interface{}, targets ...string) {
+	t.recordValue(value, func(scratch *bjson, value interface{}, targets []string) error {
+		return scratch.SetElement(value, targets...)
+	}, targets)
+}
+
+func (t *txn) Remove(targets ...string) {
+	t.recordValue(nil, func(scratch *bjson, _ interface{}, targets []string) error {
+		return scratch.RemoveElement(targets...)
+	}, targets)
+}
+
+func (t *txn) Escape(targets ...string) {
+	t.recordValue(nil, func(scratch *bjson, _ interface{}, targets []string) error {
+		return scratch.EscapeElement(targets...)
+	}, targets)
+}
+
+func (t *txn) Commit() error {
+	if t.isClosed {
+		return errors.New("transaction is already closed")
+	}
+	t.isClosed = true
+
+	if t.err != nil {
+		return t.err
+	}
+
+	return t.bj.atomic(func(scratch *bjson) error {
+		for idx, op := range t.ops {
+			if err := op(scratch); err != nil {
+				return fmt.Errorf("fail to apply operation %v of transaction. %v", idx, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (t *txn) Rollback() {
+	t.isClosed = true
+	t.ops = nil
+}
+
+func (t *txn) recordValue(value interface{}, op func(scratch *bjson, value interface{}, targets []string) error, targets []string) {
+	if t.isClosed || t.err != nil {
+		return
+	}
+
+	if value != nil {
+		var err error
+		value, err = deepCopy(value, t.bj.useNumber)
+		if err != nil {
+			t.err = fmt.Errorf("fail to record operation %v of transaction. %v", len(t.ops), err)
+			return
+		}
+	}
+
+	targets = append([]string{}, targets...)
+	t.ops = append(t.ops, func(scratch *bjson) error {
+		return op(scratch, value, targets)
+	})
+}
+
+// Atomic calls fn with a deep copy of the document and replaces the document with that copy
+// only when fn succeeds. When fn fails the copy is discarded and the document is left
+// unchanged. scratch must not be used after fn returns.
+func (bj *bjson) Atomic(fn func(scratch BJSON) error) error {
+	return bj.atomic(func(scratch *bjson) error {
+		return fn(scratch)
+	})
+}
+
+// atomic runs fn against a deep copy of the document and only replaces the document
+// with the copy when fn succeeds.
+func (bj *bjson) atomic(fn func(scratch *bjson) error) error {
+	nVal, err := deepCopy(bj.value, bj.useNumber)
+	if err != nil {
+		return err
+	}
+
+	scratch := &bjson{value: nVal, marshalRoot: appendPath(nil, bj.marshalRoot...), useNumber: bj.useNumber}
+	if err = fn(scratch); err != nil {
+		return err
+	}
+
+	bj.value, bj.marshalRoot = scratch.value, scratch.marshalRoot
+	return nil
+}
@@ -0,0 +1,166 @@
+package bjson
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_bjson_Begin(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		record     func(txn Txn)
+		isRollback bool
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:   "success - commit operations in order",
+			fields: fields{value: `{"a":{"b":1},"arr":[1,2,3],"c":"x"}`},
+			record: func(txn Txn) {
+				txn.Add("new", "d")
+				txn.Set(2, "a", "b")
+				txn.Remove("arr", "0")
+				txn.Add(4, "arr")
+				txn.Escape("a")
+			},
+			want:    `{"a":"{\"b\":2}","arr":[2,3,4],"c":"x","d":"new"}`,
+			wantErr: false,
+		},
+		{
+			name:   "success - rollback discards operations",
+			fields: fields{value: `{"a":1}`},
+			record: func(txn Txn) {
+				txn.Set(2, "a")
+			},
+			isRollback: true,
+			want:       `{"a":1}`,
+			wantErr:    true,
+		},
+		{
+			name:   "fail - failing operation leaves the document unchanged",
+			fields: fields{value: `{"a":1}`},
+			record: func(txn Txn) {
+				txn.Set(2, "a")
+				txn.Remove("missing")
+			},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+		{
+			name:   "fail - value cannot be recorded",
+			fields: fields{value: `{"a":1}`},
+			record: func(txn Txn) {
+				txn.Set(2, "a")
+				txn.Set(func() {}, "a")
+			},
+			want:    `{"a":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			txn := bj.Begin()
+			tt.record(txn)
+			assert.Equal(t, tt.fields.value, bj.String())
+			if tt.isRollback {
+				txn.Rollback()
+			}
+
+			err = txn.Commit()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+			assert.Error(t, txn.Commit())
+		})
+	}
+}
+
+func Test_bjson_Atomic(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		fn func(scratch BJSON) error
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "success - apply every step",
+			fields: fields{value: `{"a":1,"b":[1]}`},
+			args: args{fn: func(scratch BJSON) error {
+				if err := scratch.SetElement(2, "a"); err != nil {
+					return err
+				}
+				if err := scratch.AddElement(2, "b"); err != nil {
+					return err
+				}
+				return scratch.AddElement("x", "c")
+			}},
+			want:    `{"a":2,"b":[1,2],"c":"x"}`,
+			wantErr: false,
+		},
+		{
+			name:   "fail - roll back on a failing step",
+			fields: fields{value: `{"a":1,"b":{"c":2}}`},
+			args: args{fn: func(scratch BJSON) error {
+				if err := scratch.SetElement(2, "a"); err != nil {
+					return err
+				}
+				if err := scratch.SetElement(3, "b", "c"); err != nil {
+					return err
+				}
+				return scratch.RemoveElement("missing")
+			}},
+			want:    `{"a":1,"b":{"c":2}}`,
+			wantErr: true,
+		},
+		{
+			name:   "fail - roll back on a custom error",
+			fields: fields{value: `[1]`},
+			args: args{fn: func(scratch BJSON) error {
+				if err := scratch.SetElement(5, "0"); err != nil {
+					return err
+				}
+				return errors.New("abort")
+			}},
+			want:    `[1]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = bj.Atomic(tt.args.fn)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, bj.String())
+		})
+	}
+}
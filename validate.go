@@ -0,0 +1,212 @@
+package bjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CheckRanges returns, in sorted order, the dot-path keys of rules (see ParsePath) whose
+// element is missing, is not a number or lies outside the inclusive [min, max] range. An
+// invalid path or a range whose min is greater than its max fails.
+func (bj *bjson) CheckRanges(rules map[string][2]float64) ([]string, error) {
+	paths := make([]string, 0, len(rules))
+	for path := range rules {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var ret []string
+	for _, path := range paths {
+		bounds := rules[path]
+		if bounds[0] > bounds[1] {
+			return nil, fmt.Errorf("invalid range for path '%v': min %v is greater than max %v", path, bounds[0], bounds[1])
+		}
+
+		targets, err := ParsePath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		sel, err := bj.getElement(newTracer(targets))
+		if err != nil {
+			ret = append(ret, path)
+			continue
+		}
+
+		num, ok := toFloat64(sel.value)
+		if !ok || num < bounds[0] || num > bounds[1] {
+			ret = append(ret, path)
+		}
+	}
+
+	return ret, nil
+}
+
+// RequireNonEmpty returns, in input order and rendered with formatPath, the paths that are
+// empty: missing, null, the empty string "", an empty JSON array or an empty JSON object.
+// Strings holding only white space, zero numbers and false are not empty.
+func (bj *bjson) RequireNonEmpty(paths [][]string) ([]string, error) {
+	var ret []string
+	for _, path := range paths {
+		sel, err := bj.getElement(newTracer(path))
+		if err != nil || isEmptyValue(sel.value) {
+			ret = append(ret, formatPath(path))
+		}
+	}
+
+	return ret, nil
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch obj := value.(type) {
+	case nil:
+		return true
+	case string:
+		return obj == ""
+	case []interface{}:
+		return len(obj) == 0
+	case map[string]interface{}:
+		return len(obj) == 0
+	}
+
+	return false
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+type structField struct {
+	name      string
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+// MatchesStruct returns the dot-paths (see formatPath), in field order, that json.Unmarshal
+// into v would expect but the document lacks. v must be a struct or a pointer to one. Fields
+// follow encoding/json rules: exported fields only, names from json tags, "-" skipped and
+// embedded structs inlined. Fields tagged omitempty are optional. Nested structs, slices,
+// arrays and maps are checked wherever the document holds a matching element; keys are
+// matched like encoding/json, preferring an exact match over a case-insensitive one.
+func (bj *bjson) MatchesStruct(v interface{}) ([]string, error) {
+	typ := reflect.TypeOf(v)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct. got: %T", v)
+	}
+
+	var ret []string
+	matchType(nil, typ, bj.value, func(path []string) {
+		ret = append(ret, formatPath(path))
+	})
+
+	return ret, nil
+}
+
+func matchType(path []string, typ reflect.Type, value interface{}, report func(path []string)) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if reflect.PtrTo(typ).Implements(unmarshalerType) {
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		for _, field := range structFields(typ) {
+			child, isExist := lookupKey(obj, field.name)
+			if !isExist {
+				if !field.omitEmpty {
+					report(appendPath(path, field.name))
+				}
+
+				continue
+			}
+
+			matchType(appendPath(path, field.name), field.typ, child, report)
+		}
+
+	case reflect.Slice, reflect.Array:
+		arr, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+
+		for idx, child := range arr {
+			matchType(appendPath(path, strconv.Itoa(idx)), typ.Elem(), child, report)
+		}
+
+	case reflect.Map:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		for _, key := range sortedKeys(obj) {
+			matchType(appendPath(path, key), typ.Elem(), obj[key], report)
+		}
+	}
+}
+
+func structFields(typ reflect.Type) []structField {
+	var ret []structField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldTyp := field.Type
+		for fieldTyp.Kind() == reflect.Ptr {
+			fieldTyp = fieldTyp.Elem()
+		}
+
+		if field.Anonymous && name == "" && fieldTyp.Kind() == reflect.Struct {
+			ret = append(ret, structFields(fieldTyp)...)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		ret = append(ret, structField{
+			name:      name,
+			typ:       field.Type,
+			omitEmpty: containsString(strings.Split(opts, ","), "omitempty"),
+		})
+	}
+
+	return ret
+}
+
+func lookupKey(obj map[string]interface{}, name string) (interface{}, bool) {
+	if child, isExist := obj[name]; isExist {
+		return child, true
+	}
+
+	for _, key := range sortedKeys(obj) {
+		if strings.EqualFold(key, name) {
+			return obj[key], true
+		}
+	}
+
+	return nil, false
+}
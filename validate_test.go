@@ -0,0 +1,218 @@
+package bjson
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_bjson_CheckRanges(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		rules map[string][2]float64
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "success - report out of range, non-numbers and missing paths",
+			fields: fields{value: `{"age":130,"score":0.5,"items":[{"qty":0},{"qty":"3"}],"min":1,"max":10}`},
+			args: args{rules: map[string][2]float64{
+				"age":          {0, 120},
+				"score":        {0, 1},
+				"items[0].qty": {1, 99},
+				"items[1].qty": {1, 99},
+				"missing":      {0, 1},
+				"min":          {1, 10},
+				"max":          {1, 10},
+			}},
+			want:    []string{"age", "items[0].qty", "items[1].qty", "missing"},
+			wantErr: false,
+		},
+		{
+			name:    "success - everything in range",
+			fields:  fields{value: `{"a":{"b":-5}}`},
+			args:    args{rules: map[string][2]float64{"a.b": {-10, 0}}},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "fail - min greater than max",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{rules: map[string][2]float64{"a": {2, 1}}},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - invalid path",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{rules: map[string][2]float64{"a.": {0, 1}}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.CheckRanges(tt.args.rules)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_RequireNonEmpty(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		paths [][]string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "success - report empty paths in input order",
+			fields: fields{value: `{"name":"","tags":[],"meta":{},"note":null,"blank":" ","zero":0,"off":false,"user":{"id":1}}`},
+			args: args{paths: [][]string{
+				{"user", "id"},
+				{"name"},
+				{"tags"},
+				{"meta"},
+				{"note"},
+				{"missing"},
+				{"user", "email"},
+				{"blank"},
+				{"zero"},
+				{"off"},
+			}},
+			want:    []string{"name", "tags", "meta", "note", "missing", "user.email"},
+			wantErr: false,
+		},
+		{
+			name:    "success - everything present",
+			fields:  fields{value: `{"a":[1],"b":{"c":"x"}}`},
+			args:    args{paths: [][]string{{"a"}, {"b", "c"}}},
+			want:    nil,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.RequireNonEmpty(tt.args.paths)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_MatchesStruct(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+		Zip  string `json:"zip,omitempty"`
+	}
+	type Base struct {
+		ID int `json:"id"`
+	}
+	type user struct {
+		Base
+		Name      string             `json:"name"`
+		Nickname  *string            `json:"nickname,omitempty"`
+		Address   address            `json:"address"`
+		Addresses []address          `json:"addresses"`
+		Tags      map[string]address `json:"tags,omitempty"`
+		Created   time.Time          `json:"created"`
+		Ignored   string             `json:"-"`
+		Plain     bool
+		internal  string
+	}
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		v interface{}
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "success - report missing nested fields",
+			fields: fields{value: `{"id":1,"NAME":"x","address":{"zip":"1"},"addresses":[{"city":"a"},{}],` +
+				`"tags":{"home":{"zip":"2"}},"created":"2023-01-01T00:00:00Z"}`},
+			args:    args{v: user{}},
+			want:    []string{"address.city", "addresses.1.city", "tags.home.city", "Plain"},
+			wantErr: false,
+		},
+		{
+			name:    "success - pointer to struct with missing objects",
+			fields:  fields{value: `{"name":"x","Plain":true,"addresses":null,"created":"2023-01-01T00:00:00Z"}`},
+			args:    args{v: &user{}},
+			want:    []string{"id", "address"},
+			wantErr: false,
+		},
+		{
+			name:    "success - shape matches",
+			fields:  fields{value: `{"city":"a","extra":1}`},
+			args:    args{v: address{}},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "fail - not a struct",
+			fields:  fields{value: `{}`},
+			args:    args{v: map[string]interface{}{}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.MatchesStruct(tt.args.v)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
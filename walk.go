@@ -0,0 +1,381 @@
+package bjson
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrSkip can be returned by the function passed to Walk to skip the elements below the
+// current JSON object or JSON array. It is not returned by Walk.
+var ErrSkip = errors.New("skip this element")
+
+type walkFunc func(path []string, value interface{}) error
+
+// Walk visits the document depth-first in pre-order, object keys in sorted order, calling fn
+// with the path and a view of every element: containers first, then their children. Returning
+// ErrSkip skips the children of the current element; any other error aborts the walk and is
+// returned. Views share memory with the document and must not be mutated during the walk.
+func (bj *bjson) Walk(fn func(path []string, value BJSON) error) error {
+	return walkElement(nil, bj.value, func(path []string, value interface{}) error {
+		return fn(path, &bjson{value: value, useNumber: bj.useNumber})
+	})
+}
+
+// ForEach calls fn with every key and value view of the JSON object at targets, in sorted key
+// order, or with every index and value view of the JSON array at targets. An error from fn
+// stops the iteration and is returned.
+func (bj *bjson) ForEach(fn func(key string, value BJSON) error, targets ...string) error {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return err
+	}
+
+	switch obj := sel.value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(obj) {
+			if err = fn(key, &bjson{value: obj[key], useNumber: bj.useNumber}); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		for idx, child := range obj {
+			if err = fn(strconv.Itoa(idx), &bjson{value: child, useNumber: bj.useNumber}); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("cannot iterate element %v. element is a json %v", parseTracerPath(targets), typeOf(sel.value))
+	}
+
+	return nil
+}
+
+// GetAllPaths returns the path of every leaf in walk order: object keys sorted and array
+// indices ascending. Scalars and null are leaves; empty JSON objects and arrays are leaves only
+// when includeEmpty is true. A scalar root yields a single empty path.
+func (bj *bjson) GetAllPaths(includeEmpty bool) [][]string {
+	var ret [][]string
+	_ = walkElement(nil, bj.value, func(path []string, value interface{}) error {
+		switch obj := value.(type) {
+		case map[string]interface{}:
+			if len(obj) > 0 || !includeEmpty {
+				return nil
+			}
+
+		case []interface{}:
+			if len(obj) > 0 || !includeEmpty {
+				return nil
+			}
+		}
+
+		ret = append(ret, path)
+		return nil
+	})
+
+	return ret
+}
+
+// Flatten collapses the document into a single-level map from the paths of its leaves (see
+// GetAllPaths), joined with delimiter, to their values. Empty JSON objects and arrays are kept
+// as values. Keys are not escaped: Flatten fails when an object key contains delimiter, as the
+// flat key would be ambiguous.
+func (bj *bjson) Flatten(delimiter string) (map[string]interface{}, error) {
+	if delimiter == "" {
+		return nil, fmt.Errorf("delimiter must not be empty")
+	}
+
+	ret := make(map[string]interface{})
+	err := walkElement(nil, bj.value, func(path []string, value interface{}) error {
+		switch obj := value.(type) {
+		case map[string]interface{}:
+			for key := range obj {
+				if strings.Contains(key, delimiter) {
+					return fmt.Errorf("key '%v' at %v contains delimiter '%v'", key, parseTracerPath(path), delimiter)
+				}
+			}
+
+			if len(obj) > 0 {
+				return nil
+			}
+
+			ret[strings.Join(path, delimiter)] = map[string]interface{}{}
+			return nil
+
+		case []interface{}:
+			if len(obj) > 0 {
+				return nil
+			}
+
+			ret[strings.Join(path, delimiter)] = []interface{}{}
+			return nil
+		}
+
+		ret[strings.Join(path, delimiter)] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// FindKey returns the path of every object member called key, at any depth, in walk order.
+func (bj *bjson) FindKey(key string) [][]string {
+	var ret [][]string
+	_ = walkElement(nil, bj.value, func(path []string, value interface{}) error {
+		if obj, ok := value.(map[string]interface{}); ok {
+			if _, isExist := obj[key]; isExist {
+				ret = append(ret, appendPath(path, key))
+			}
+		}
+
+		return nil
+	})
+
+	return ret
+}
+
+// FindValue returns, in walk order, the path of every scalar or null leaf equal to value:
+// numbers by value, anything else exactly. value is normalized like SetElement values, so
+// native Go types may be used. A value that cannot be normalized or is a JSON object or array
+// matches nothing.
+func (bj *bjson) FindValue(value interface{}) [][]string {
+	target, err := deepCopy(value, bj.useNumber)
+	if err != nil {
+		return nil
+	}
+
+	switch target.(type) {
+	case map[string]interface{}, []interface{}:
+		return nil
+	}
+
+	var ret [][]string
+	_ = walkElement(nil, bj.value, func(path []string, value interface{}) error {
+		if valuesEqual(value, target) {
+			ret = append(ret, path)
+		}
+
+		return nil
+	})
+
+	return ret
+}
+
+func (bj *bjson) NodeCount(targets ...string) (int, error) {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	_ = walkElement(nil, sel.value, func(path []string, value interface{}) error {
+		count++
+		return nil
+	})
+
+	return count, nil
+}
+
+// Index maps the canonical dot-path of every element (see formatPath) to a view of that
+// element, so repeated lookups do not traverse the document again. The root is keyed by
+// an empty string. The index is a snapshot: the views share memory with the document and
+// any later mutation of the document invalidates it.
+func (bj *bjson) Index() (map[string]BJSON, error) {
+	ret := make(map[string]BJSON)
+	err := walkElement(nil, bj.value, func(path []string, value interface{}) error {
+		ret[formatPath(path)] = &bjson{value: value, useNumber: bj.useNumber}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// KeyDepths counts the occurrences of JSON object keys called name by depth, where keys of the
+// root object are at depth 1.
+func (bj *bjson) KeyDepths(name string) (map[int]int, error) {
+	ret := make(map[int]int)
+	err := walkElement(nil, bj.value, func(path []string, value interface{}) error {
+		if obj, ok := value.(map[string]interface{}); ok {
+			if _, isExist := obj[name]; isExist {
+				ret[len(path)+1]++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Where visits the document depth-first in pre-order, object keys in sorted order, and returns
+// every element, the root included, for which pred returns true. Values share memory with the
+// document.
+func (bj *bjson) Where(pred func(path []string, value BJSON) bool) ([]Match, error) {
+	var ret []Match
+	err := walkElement(nil, bj.value, func(path []string, value interface{}) error {
+		sel := &bjson{value: value, useNumber: bj.useNumber}
+		if pred(path, sel) {
+			ret = append(ret, Match{Path: path, Value: sel})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// InternStrings makes every string leaf equal to an earlier one, in walk order, share the
+// memory of that earlier string and returns how many leaves were deduplicated. The document
+// content is unchanged; only its memory footprint shrinks.
+func (bj *bjson) InternStrings() int {
+	var (
+		pool  = make(map[string]string)
+		count int
+	)
+
+	intern := func(str string) string {
+		if shared, isExist := pool[str]; isExist {
+			count++
+			return shared
+		}
+
+		pool[str] = str
+		return str
+	}
+
+	_ = walkElement(nil, bj.value, func(path []string, value interface{}) error {
+		switch obj := value.(type) {
+		case map[string]interface{}:
+			for _, key := range sortedKeys(obj) {
+				if str, ok := obj[key].(string); ok {
+					obj[key] = intern(str)
+				}
+			}
+
+		case []interface{}:
+			for idx, child := range obj {
+				if str, ok := child.(string); ok {
+					obj[idx] = intern(str)
+				}
+			}
+		}
+
+		return nil
+	})
+
+	return count
+}
+
+// walkElement visits value and every element below it depth-first in pre-order.
+// Object keys are visited in sorted order and array elements by index. Every path
+// passed to fn is a fresh slice, so it is safe to retain. When fn returns ErrSkip
+// the elements below value are not visited.
+func walkElement(path []string, value interface{}, fn walkFunc) error {
+	if err := fn(path, value); err != nil {
+		if errors.Is(err, ErrSkip) {
+			return nil
+		}
+
+		return err
+	}
+
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(obj) {
+			if err := walkElement(appendPath(path, key), obj[key], fn); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		for idx, child := range obj {
+			if err := walkElement(appendPath(path, strconv.Itoa(idx)), child, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func appendPath(path []string, targets ...string) []string {
+	ret := make([]string, len(path), len(path)+len(targets))
+	copy(ret, path)
+	return append(ret, targets...)
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+type transformFunc func(path []string, value interface{}) (interface{}, error)
+
+// transformLeaves replaces every leaf under targets with the value returned by fn.
+// The document is only updated once every leaf is transformed successfully.
+func (bj *bjson) transformLeaves(targets []string, fn transformFunc) error {
+	sel, err := bj.getElement(newTracer(targets))
+	if err != nil {
+		return err
+	}
+
+	nVal, err := transformElement(nil, sel.value, fn)
+	if err != nil {
+		return err
+	}
+
+	return bj.SetElement(nVal, targets...)
+}
+
+// transformElement rebuilds value bottom-up with every leaf replaced by the result of fn,
+// leaving value itself untouched.
+func transformElement(path []string, value interface{}, fn transformFunc) (interface{}, error) {
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(obj))
+		for _, key := range sortedKeys(obj) {
+			child, err := transformElement(appendPath(path, key), obj[key], fn)
+			if err != nil {
+				return nil, err
+			}
+			ret[key] = child
+		}
+
+		return ret, nil
+
+	case []interface{}:
+		ret := make([]interface{}, len(obj))
+		for idx, v := range obj {
+			child, err := transformElement(appendPath(path, strconv.Itoa(idx)), v, fn)
+			if err != nil {
+				return nil, err
+			}
+			ret[idx] = child
+		}
+
+		return ret, nil
+	}
+
+	return fn(path, value)
+}
@@ -0,0 +1,698 @@
+package bjson
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func Test_bjson_NodeCount(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "success - count scalar root",
+			fields:  fields{value: `"test"`},
+			args:    args{targets: nil},
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "success - count nested document",
+			fields:  fields{value: `{"a":1,"b":[1,2,{"c":null}],"d":{}}`},
+			args:    args{targets: nil},
+			want:    8,
+			wantErr: false,
+		},
+		{
+			name:    "success - count subtree",
+			fields:  fields{value: `{"a":1,"b":[1,2,{"c":null}],"d":{}}`},
+			args:    args{targets: []string{"b"}},
+			want:    5,
+			wantErr: false,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{targets: []string{"b"}},
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.NodeCount(tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_Index(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   map[string]string
+	}{
+		{
+			name:   "success - index scalar root",
+			fields: fields{value: `"test"`},
+			want:   map[string]string{"": `"test"`},
+		},
+		{
+			name:   "success - index nested document",
+			fields: fields{value: `{"a":{"b":[1,{"c":true}]},"d.e":null}`},
+			want: map[string]string{
+				"":        `{"a":{"b":[1,{"c":true}]},"d.e":null}`,
+				"a":       `{"b":[1,{"c":true}]}`,
+				"a.b":     `[1,{"c":true}]`,
+				"a.b.0":   `1`,
+				"a.b.1":   `{"c":true}`,
+				"a.b.1.c": `true`,
+				`d\.e`:    `null`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.Index()
+			assert.NoError(t, err)
+
+			gotStr := make(map[string]string, len(got))
+			for k, v := range got {
+				gotStr[k] = v.String()
+			}
+			assert.Equal(t, tt.want, gotStr)
+		})
+	}
+}
+
+func Test_bjson_KeyDepths(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		name string
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   map[int]int
+	}{
+		{
+			name:   "success - key at many depths",
+			fields: fields{value: `{"id":1,"a":{"id":2,"b":[{"id":3},{"id":4},{"x":{"id":5}}]},"c":{"ids":1}}`},
+			args:   args{name: "id"},
+			want:   map[int]int{1: 1, 2: 1, 4: 2, 5: 1},
+		},
+		{
+			name:   "success - array indices are not keys",
+			fields: fields{value: `{"a":["x","y"]}`},
+			args:   args{name: "0"},
+			want:   map[int]int{},
+		},
+		{
+			name:   "success - key is not found",
+			fields: fields{value: `"id"`},
+			args:   args{name: "id"},
+			want:   map[int]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.KeyDepths(tt.args.name)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_InternStrings(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   int
+	}{
+		{
+			name:   "success - deduplicate repeated string leaves",
+			fields: fields{value: `{"a":"ok","b":["ok","fail","ok"],"c":{"d":"fail","e":"ok"},"f":1}`},
+			want:   4,
+		},
+		{
+			name:   "success - keys are not string leaves",
+			fields: fields{value: `{"ok":"x","x":{"ok":1}}`},
+			want:   0,
+		},
+		{
+			name:   "success - scalar root",
+			fields: fields{value: `"ok"`},
+			want:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := bj.String()
+			assert.Equal(t, tt.want, bj.InternStrings())
+			assert.Equal(t, before, bj.String())
+		})
+	}
+}
+
+func Test_bjson_Where(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		pred func(path []string, value BJSON) bool
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantPaths [][]string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:   "success - match by value in pre-order",
+			fields: fields{value: `{"b":{"secret":"x","n":1},"a":[{"secret":"y"},2]}`},
+			args: args{pred: func(path []string, value BJSON) bool {
+				return len(path) > 0 && path[len(path)-1] == "secret"
+			}},
+			wantPaths: [][]string{{"a", "0", "secret"}, {"b", "secret"}},
+			want:      []string{`"y"`, `"x"`},
+			wantErr:   false,
+		},
+		{
+			name:   "success - match containers and root",
+			fields: fields{value: `{"a":[1],"b":{}}`},
+			args: args{pred: func(path []string, value BJSON) bool {
+				return strings.ContainsAny(value.String()[:1], "{[")
+			}},
+			wantPaths: [][]string{nil, {"a"}, {"b"}},
+			want:      []string{`{"a":[1],"b":{}}`, `[1]`, `{}`},
+			wantErr:   false,
+		},
+		{
+			name:   "success - no match",
+			fields: fields{value: `[1,2]`},
+			args: args{pred: func(path []string, value BJSON) bool {
+				return false
+			}},
+			wantPaths: nil,
+			want:      nil,
+			wantErr:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.Where(tt.args.pred)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var gotPaths [][]string
+			var gotValues []string
+			for _, match := range got {
+				gotPaths = append(gotPaths, match.Path)
+				gotValues = append(gotValues, match.Value.String())
+			}
+			assert.Equal(t, tt.wantPaths, gotPaths)
+			assert.Equal(t, tt.want, gotValues)
+		})
+	}
+}
+
+func Test_bjson_Walk(t *testing.T) {
+	errAbort := errors.New("abort")
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		skipAt  string
+		abortAt string
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantPaths  []string
+		wantLeaves int
+		wantErr    error
+	}{
+		{
+			name:       "success - visit every element",
+			fields:     fields{value: `{"b":[1,{"c":null}],"a":"x"}`},
+			args:       args{skipAt: "-", abortAt: "-"},
+			wantPaths:  []string{"", "a", "b", "b.0", "b.1", "b.1.c"},
+			wantLeaves: 3,
+			wantErr:    nil,
+		},
+		{
+			name:       "success - skip nested object",
+			fields:     fields{value: `{"a":{"x":1,"y":{"z":2}},"b":true}`},
+			args:       args{skipAt: "a", abortAt: "-"},
+			wantPaths:  []string{"", "a", "b"},
+			wantLeaves: 1,
+			wantErr:    nil,
+		},
+		{
+			name:       "success - skip on a leaf is ignored",
+			fields:     fields{value: `[1,2]`},
+			args:       args{skipAt: "0", abortAt: "-"},
+			wantPaths:  []string{"", "0", "1"},
+			wantLeaves: 2,
+			wantErr:    nil,
+		},
+		{
+			name:       "fail - abort walk",
+			fields:     fields{value: `{"a":1,"b":2,"c":3}`},
+			args:       args{skipAt: "-", abortAt: "b"},
+			wantPaths:  []string{"", "a", "b"},
+			wantLeaves: 2,
+			wantErr:    errAbort,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				gotPaths  []string
+				gotLeaves int
+			)
+			err = bj.Walk(func(path []string, value BJSON) error {
+				gotPaths = append(gotPaths, formatPath(path))
+				if typ, _ := value.TypeOf(); typ != JSONTypeObject && typ != JSONTypeArray {
+					gotLeaves++
+				}
+
+				switch formatPath(path) {
+				case tt.args.skipAt:
+					return ErrSkip
+				case tt.args.abortAt:
+					return errAbort
+				}
+
+				return nil
+			})
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantPaths, gotPaths)
+			assert.Equal(t, tt.wantLeaves, gotLeaves)
+		})
+	}
+}
+
+func Test_bjson_ForEach(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		stopAt  string
+		targets []string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "success - object in sorted key order",
+			fields:  fields{value: `{"x":{"c":3,"a":1,"b":[2]}}`},
+			args:    args{stopAt: "", targets: []string{"x"}},
+			want:    []string{"a=1", "b=[2]", "c=3"},
+			wantErr: false,
+		},
+		{
+			name:    "success - array indices",
+			fields:  fields{value: `["a",null,true]`},
+			args:    args{stopAt: "", targets: nil},
+			want:    []string{`0="a"`, "1=null", "2=true"},
+			wantErr: false,
+		},
+		{
+			name:    "success - empty object",
+			fields:  fields{value: `{"x":{}}`},
+			args:    args{stopAt: "", targets: []string{"x"}},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "fail - error from fn stops iteration",
+			fields:  fields{value: `[1,2,3]`},
+			args:    args{stopAt: "1", targets: nil},
+			want:    []string{"0=1", "1=2"},
+			wantErr: true,
+		},
+		{
+			name:    "fail - scalar target",
+			fields:  fields{value: `{"x":"str"}`},
+			args:    args{stopAt: "", targets: []string{"x"}},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - element is not found",
+			fields:  fields{value: `{}`},
+			args:    args{stopAt: "", targets: []string{"x"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			err = bj.ForEach(func(key string, value BJSON) error {
+				got = append(got, key+"="+value.String())
+				if key == tt.args.stopAt {
+					return errors.New("stop")
+				}
+
+				return nil
+			}, tt.args.targets...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_GetAllPaths(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		includeEmpty bool
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   []string
+	}{
+		{
+			name:   "success - nested mixed document",
+			fields: fields{value: `{"data":{"phone":["1",{"ext":2}],"name":null},"a":true,"e":{},"l":[]}`},
+			args:   args{includeEmpty: false},
+			want:   []string{"a", "data.name", "data.phone.0", "data.phone.1.ext"},
+		},
+		{
+			name:   "success - include empty containers",
+			fields: fields{value: `{"data":{"phone":["1",{"ext":2}],"name":null},"a":true,"e":{},"l":[[]]}`},
+			args:   args{includeEmpty: true},
+			want:   []string{"a", "data.name", "data.phone.0", "data.phone.1.ext", "e", "l.0"},
+		},
+		{
+			name:   "success - array indices in numeric order",
+			fields: fields{value: `[0,1,2,3,4,5,6,7,8,9,10]`},
+			args:   args{includeEmpty: false},
+			want:   []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+		},
+		{
+			name:   "success - scalar root",
+			fields: fields{value: `"x"`},
+			args:   args{includeEmpty: false},
+			want:   []string{""},
+		},
+		{
+			name:   "success - empty root",
+			fields: fields{value: `{}`},
+			args:   args{includeEmpty: false},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, path := range bj.GetAllPaths(tt.args.includeEmpty) {
+				got = append(got, strings.Join(path, "."))
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_Flatten(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		delimiter string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "success - nested objects and arrays",
+			fields: fields{value: `{"data":{"phone":{"foo":"bar"},"tags":["a",{"b":true}]},"n":null}`},
+			args:   args{delimiter: "."},
+			want: map[string]interface{}{
+				"data.phone.foo": "bar",
+				"data.tags.0":    "a",
+				"data.tags.1.b":  true,
+				"n":              nil,
+			},
+			wantErr: false,
+		},
+		{
+			name:   "success - empty containers and custom delimiter",
+			fields: fields{value: `{"a.b":{"c":{},"d":[]},"e":[1.5]}`},
+			args:   args{delimiter: "/"},
+			want: map[string]interface{}{
+				"a.b/c": map[string]interface{}{},
+				"a.b/d": []interface{}{},
+				"e/0":   1.5,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "success - scalar root",
+			fields:  fields{value: `1`},
+			args:    args{delimiter: "."},
+			want:    map[string]interface{}{"": float64(1)},
+			wantErr: false,
+		},
+		{
+			name:    "fail - key contains delimiter",
+			fields:  fields{value: `{"a":{"b.c":1}}`},
+			args:    args{delimiter: "."},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "fail - empty delimiter",
+			fields:  fields{value: `{"a":1}`},
+			args:    args{delimiter: ""},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bj.Flatten(tt.args.delimiter)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_FindKey(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		key string
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   []string
+	}{
+		{
+			name:   "success - key at multiple depths and in array elements",
+			fields: fields{value: `{"id":1,"users":[{"id":2,"tags":[{"id":3}]},{"name":"x"},{"id":null}],"meta":{"owner":{"id":4}}}`},
+			args:   args{key: "id"},
+			want:   []string{"id", "meta.owner.id", "users.0.id", "users.0.tags.0.id", "users.2.id"},
+		},
+		{
+			name:   "success - key holding a container",
+			fields: fields{value: `{"a":{"a":{"b":1}}}`},
+			args:   args{key: "a"},
+			want:   []string{"a", "a.a"},
+		},
+		{
+			name:   "success - key is not found",
+			fields: fields{value: `[{"a":1},"id"]`},
+			args:   args{key: "id"},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, path := range bj.FindKey(tt.args.key) {
+				got = append(got, strings.Join(path, "."))
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bjson_FindValue(t *testing.T) {
+	type fields struct {
+		value interface{}
+	}
+	type args struct {
+		value interface{}
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   []string
+	}{
+		{
+			name:   "success - number under two paths",
+			fields: fields{value: `{"a":{"count":2},"list":[1,2.0,"2"],"b":2.5}`},
+			args:   args{value: 2},
+			want:   []string{"a.count", "list.1"},
+		},
+		{
+			name:   "success - string value",
+			fields: fields{value: `{"a":"x","b":["x","X",{"c":"x"}],"d":"xx"}`},
+			args:   args{value: "x"},
+			want:   []string{"a", "b.0", "b.2.c"},
+		},
+		{
+			name:   "success - bool and null",
+			fields: fields{value: `[true,null,false,{"n":null}]`},
+			args:   args{value: nil},
+			want:   []string{"1", "3.n"},
+		},
+		{
+			name:   "success - scalar root",
+			fields: fields{value: `true`},
+			args:   args{value: true},
+			want:   []string{""},
+		},
+		{
+			name:   "success - containers match nothing",
+			fields: fields{value: `{"a":[1],"b":[1]}`},
+			args:   args{value: []int{1}},
+			want:   nil,
+		},
+		{
+			name:   "fail - value cannot be normalized",
+			fields: fields{value: `{"a":1}`},
+			args:   args{value: func() {}},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bj, err := NewBJSON(tt.fields.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, path := range bj.FindValue(tt.args.value) {
+				got = append(got, strings.Join(path, "."))
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}